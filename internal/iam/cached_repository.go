@@ -0,0 +1,414 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/boundary/internal/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cacheMetricsSubsystem is used as the Prometheus subsystem for every
+// counter registered by a CachedRepository.
+const cacheMetricsSubsystem = "iam_scope_cache"
+
+var (
+	cacheHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: cacheMetricsSubsystem,
+		Name:      "hits_total",
+		Help:      "Number of cache hits, by entry kind.",
+	}, []string{"kind"})
+	cacheMisses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: cacheMetricsSubsystem,
+		Name:      "misses_total",
+		Help:      "Number of cache misses, by entry kind.",
+	}, []string{"kind"})
+	cacheEvictions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: cacheMetricsSubsystem,
+		Name:      "evictions_total",
+		Help:      "Number of cache evictions, by entry kind and reason (ttl, capacity, invalidated).",
+	}, []string{"kind", "reason"})
+)
+
+func init() {
+	prometheus.MustRegister(cacheHits, cacheMisses, cacheEvictions)
+}
+
+// cacheEntry holds a cached value alongside the scope Version it was read
+// at (used as the cache-buster: a mutation bumps Version, so an entry only
+// needs to be invalidated explicitly, not re-validated against the DB on
+// every hit), its kind (for the eviction metric's label), and when it
+// expires.
+type cacheEntry struct {
+	kind      string
+	value     interface{}
+	version   uint32
+	expiresAt time.Time
+}
+
+// scopeCache is a small TTL+max-size cache. It intentionally doesn't try to
+// be a general-purpose LRU: entries are evicted on expiry, explicit
+// invalidation, or (if the map grows past maxEntries) at random, since
+// authz's access pattern is a handful of hot scopes re-read constantly, not
+// a working set large enough for eviction order to matter.
+type scopeCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]cacheEntry
+}
+
+func newScopeCache(ttl time.Duration, maxEntries int) *scopeCache {
+	return &scopeCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]cacheEntry),
+	}
+}
+
+func (c *scopeCache) get(kind, key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		cacheMisses.WithLabelValues(kind).Inc()
+		return nil, false
+	}
+	if time.Now().After(e.expiresAt) {
+		delete(c.entries, key)
+		cacheEvictions.WithLabelValues(kind, "ttl").Inc()
+		cacheMisses.WithLabelValues(kind).Inc()
+		return nil, false
+	}
+	cacheHits.WithLabelValues(kind).Inc()
+	return e.value, true
+}
+
+func (c *scopeCache) set(kind, key string, version uint32, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxEntries {
+		for k, e := range c.entries {
+			delete(c.entries, k)
+			cacheEvictions.WithLabelValues(e.kind, "capacity").Inc()
+			break
+		}
+	}
+	c.entries[key] = cacheEntry{kind: kind, value: value, version: version, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// invalidate removes key from the cache, if present, recording the
+// eviction as caller-driven invalidation rather than a TTL expiry or
+// capacity eviction.
+func (c *scopeCache) invalidate(kind, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok {
+		delete(c.entries, key)
+		cacheEvictions.WithLabelValues(kind, "invalidated").Inc()
+	}
+}
+
+// CachedRepository wraps a Repository with an in-process, TTL-based cache
+// in front of the handful of reads (LookupScope, ListOrgs, ListProjects,
+// and scope grant resolution) that authz checks issue on every request.
+// Entries are keyed by (publicId, version) conceptually: the cache stores
+// the Version a scope was read at and relies on every scope-mutating
+// method being called through this wrapper to invalidate stale entries,
+// rather than re-checking Version against the database on every hit.
+//
+// That includes every mutation CachedRepository overrides below, not just
+// CreateScope/UpdateScope/DeleteScope: Go embedding has no virtual
+// dispatch, so CreateScopes, MoveScope, SyncScopesFromClaims,
+// PruneUnclaimedScopes, and ImportScopeTree -- all defined on *Repository
+// and creating, moving, or deleting scopes without going through this
+// wrapper's CreateScope/DeleteScope overrides -- would otherwise always
+// hit Repository's own methods even when invoked through a
+// *CachedRepository value, leaving stale entries in the cache until TTL
+// expiry. Each of
+// those is overridden here too, invalidating the same caches its
+// Repository counterpart would have invalidated had it gone through this
+// wrapper.
+type CachedRepository struct {
+	*Repository
+	cache *scopeCache
+}
+
+// invalidateScopeCreated invalidates the org/project listings a newly
+// created scope under parentId would appear in -- the same caches
+// CreateScope's own override below invalidates -- for scopes created by a
+// method that doesn't route through this wrapper's CreateScope override.
+func (c *CachedRepository) invalidateScopeCreated(parentId string) {
+	c.cache.invalidate("orgs", "orgs")
+	if parentId != "" {
+		c.cache.invalidate("projects", "projects:"+parentId)
+	}
+}
+
+// invalidateScopeDeleted mirrors DeleteScope's own override below, for
+// scopes deleted by a method that doesn't route through it.
+func (c *CachedRepository) invalidateScopeDeleted(scopeId, parentId string) {
+	c.cache.invalidate("scope", scopeId)
+	c.cache.invalidate("grants", scopeId)
+	c.cache.invalidate("orgs", "orgs")
+	if parentId != "" {
+		c.cache.invalidate("projects", "projects:"+parentId)
+	}
+}
+
+// NewCachedRepository returns a CachedRepository wrapping inner. Entries
+// live for ttl and the cache holds at most maxEntries entries in total:
+// scope metadata, grant sets, and org/project listings all count against
+// the same limit.
+func NewCachedRepository(inner *Repository, ttl time.Duration, maxEntries int) (*CachedRepository, error) {
+	if inner == nil {
+		return nil, fmt.Errorf("new cached repository: missing repository: %w", errors.ErrInvalidParameter)
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("new cached repository: ttl must be positive: %w", errors.ErrInvalidParameter)
+	}
+	if maxEntries <= 0 {
+		return nil, fmt.Errorf("new cached repository: maxEntries must be positive: %w", errors.ErrInvalidParameter)
+	}
+	return &CachedRepository{
+		Repository: inner,
+		cache:      newScopeCache(ttl, maxEntries),
+	}, nil
+}
+
+// LookupScope behaves like Repository.LookupScope, serving cached scope
+// metadata when available.
+func (c *CachedRepository) LookupScope(ctx context.Context, withPublicId string, opt ...Option) (*Scope, error) {
+	if v, ok := c.cache.get("scope", withPublicId); ok {
+		return v.(*Scope), nil
+	}
+	s, err := c.Repository.LookupScope(ctx, withPublicId, opt...)
+	if err != nil {
+		return nil, err
+	}
+	if s != nil {
+		c.cache.set("scope", withPublicId, s.Version, s)
+	}
+	return s, nil
+}
+
+// ListOrgs behaves like Repository.ListOrgs, serving a cached result list
+// when available. The result is cached as a whole, keyed independently of
+// any single org's Version, and is invalidated whenever this wrapper
+// observes an org created, updated, or deleted.
+func (c *CachedRepository) ListOrgs(ctx context.Context, opt ...Option) ([]*Scope, error) {
+	const key = "orgs"
+	if v, ok := c.cache.get("orgs", key); ok {
+		return v.([]*Scope), nil
+	}
+	orgs, err := c.Repository.ListOrgs(ctx, opt...)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.set("orgs", key, 0, orgs)
+	return orgs, nil
+}
+
+// ListProjects behaves like Repository.ListProjects, serving a cached
+// result list per org when available.
+func (c *CachedRepository) ListProjects(ctx context.Context, withOrgId string, opt ...Option) ([]*Scope, error) {
+	key := "projects:" + withOrgId
+	if v, ok := c.cache.get("projects", key); ok {
+		return v.([]*Scope), nil
+	}
+	projects, err := c.Repository.ListProjects(ctx, withOrgId, opt...)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.set("projects", key, 0, projects)
+	return projects, nil
+}
+
+// ScopeGrants returns the canonical grant strings for every role owned by
+// scopeId, caching the derived set the same way LookupScope caches scope
+// metadata. This is the set authz checks actually consult on every
+// request, so it's cached independently of (and alongside) the scope
+// itself.
+func (c *CachedRepository) ScopeGrants(ctx context.Context, scopeId string) ([]string, error) {
+	if v, ok := c.cache.get("grants", scopeId); ok {
+		return v.([]string), nil
+	}
+	var roles []*Role
+	if err := c.reader.SearchWhere(ctx, &roles, "scope_id = ?", []interface{}{scopeId}); err != nil {
+		return nil, fmt.Errorf("scope grants: unable to list roles for %s: %w", scopeId, err)
+	}
+	roleIds := make([]string, 0, len(roles))
+	for _, role := range roles {
+		roleIds = append(roleIds, role.PublicId)
+	}
+	var grants []*RoleGrant
+	if len(roleIds) > 0 {
+		if err := c.reader.SearchWhere(ctx, &grants, "role_id in (?)", []interface{}{roleIds}); err != nil {
+			return nil, fmt.Errorf("scope grants: unable to list grants for %s: %w", scopeId, err)
+		}
+	}
+	canonical := make([]string, 0, len(grants))
+	for _, g := range grants {
+		canonical = append(canonical, g.CanonicalGrant)
+	}
+	c.cache.set("grants", scopeId, 0, canonical)
+	return canonical, nil
+}
+
+// CreateScope behaves like Repository.CreateScope. Org/project listings
+// are invalidated so a subsequent ListOrgs/ListProjects observes the new
+// scope.
+func (c *CachedRepository) CreateScope(ctx context.Context, s *Scope, userId string, opt ...Option) (*Scope, error) {
+	created, err := c.Repository.CreateScope(ctx, s, userId, opt...)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.invalidate("orgs", "orgs")
+	if created.ParentId != "" {
+		c.cache.invalidate("projects", "projects:"+created.ParentId)
+	}
+	return created, nil
+}
+
+// CreateScopes behaves like Repository.CreateScopes, invalidating the
+// org/project listings for every scope it creates.
+func (c *CachedRepository) CreateScopes(ctx context.Context, scopes []*Scope, userId string, opt ...Option) ([]*Scope, error) {
+	created, err := c.Repository.CreateScopes(ctx, scopes, userId, opt...)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range created {
+		c.invalidateScopeCreated(s.ParentId)
+	}
+	return created, nil
+}
+
+// UpdateScope behaves like Repository.UpdateScope, invalidating the cached
+// entry and grant set for the updated scope, and the cached org listing.
+// Any successful update drops them; the Version an entry was cached at is
+// not compared. The parent's cached ListProjects entry is not invalidated.
+func (c *CachedRepository) UpdateScope(ctx context.Context, s *Scope, version uint32, fieldMaskPaths []string, opt ...Option) (*Scope, int, error) {
+	updated, rowsUpdated, err := c.Repository.UpdateScope(ctx, s, version, fieldMaskPaths, opt...)
+	if err != nil {
+		return nil, rowsUpdated, err
+	}
+	c.cache.invalidate("scope", s.PublicId)
+	c.cache.invalidate("grants", s.PublicId)
+	c.cache.invalidate("orgs", "orgs")
+	return updated, rowsUpdated, nil
+}
+
+// DeleteScope behaves like Repository.DeleteScope, invalidating the cached
+// entry for the deleted scope and the parent's listing. The parent is
+// looked up through the underlying repository rather than relying on an
+// incidental cache hit, so the parent's ListProjects entry is still
+// invalidated even if withPublicId was never cached, expired, or was last
+// looked up through the uncached Repository.
+func (c *CachedRepository) DeleteScope(ctx context.Context, withPublicId string, opt ...Option) (int, error) {
+	var parentId string
+	if cached, ok := c.cache.get("scope", withPublicId); ok {
+		parentId = cached.(*Scope).ParentId
+	} else if s, err := c.Repository.LookupScope(ctx, withPublicId); err != nil {
+		return 0, err
+	} else if s != nil {
+		parentId = s.ParentId
+	}
+	rowsDeleted, err := c.Repository.DeleteScope(ctx, withPublicId, opt...)
+	if err != nil {
+		return rowsDeleted, err
+	}
+	c.cache.invalidate("scope", withPublicId)
+	c.cache.invalidate("grants", withPublicId)
+	c.cache.invalidate("orgs", "orgs")
+	if parentId != "" {
+		c.cache.invalidate("projects", "projects:"+parentId)
+	}
+	return rowsDeleted, nil
+}
+
+// MoveScope behaves like Repository.MoveScope, invalidating the moved
+// scope's own cached entry and grants, the old and new parent's
+// ListProjects entries, and the old parent's cached grants (which
+// WithSeverOldParentPrincipals may have changed).
+func (c *CachedRepository) MoveScope(ctx context.Context, publicId, newParentId string, version uint32, opt ...Option) (*Scope, error) {
+	var oldParentId string
+	if cached, ok := c.cache.get("scope", publicId); ok {
+		oldParentId = cached.(*Scope).ParentId
+	} else if s, err := c.Repository.LookupScope(ctx, publicId); err != nil {
+		return nil, err
+	} else if s != nil {
+		oldParentId = s.ParentId
+	}
+
+	moved, err := c.Repository.MoveScope(ctx, publicId, newParentId, version, opt...)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.invalidate("scope", publicId)
+	c.cache.invalidate("grants", publicId)
+	if oldParentId != "" {
+		c.cache.invalidate("projects", "projects:"+oldParentId)
+		c.cache.invalidate("grants", oldParentId)
+	}
+	c.cache.invalidate("projects", "projects:"+newParentId)
+	return moved, nil
+}
+
+// SyncScopesFromClaims behaves like Repository.SyncScopesFromClaims,
+// invalidating the org/project listings a newly created scope could
+// appear in. SyncScopesFromClaims creates scopes by calling
+// Repository.CreateScope directly rather than through this wrapper, so
+// this override can't tell which of mapping's rules actually provisioned
+// a new scope this call; it conservatively invalidates every rule's
+// ParentScopeId.
+func (c *CachedRepository) SyncScopesFromClaims(ctx context.Context, userId string, claims map[string]interface{}, mapping ScopeClaimMapping) ([]*Scope, error) {
+	synced, err := c.Repository.SyncScopesFromClaims(ctx, userId, claims, mapping)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range mapping.Rules {
+		c.invalidateScopeCreated(rule.ParentScopeId)
+	}
+	return synced, nil
+}
+
+// PruneUnclaimedScopes behaves like Repository.PruneUnclaimedScopes,
+// invalidating the cache for every scope it deletes the same way
+// DeleteScope's override does.
+func (c *CachedRepository) PruneUnclaimedScopes(ctx context.Context, mapping ScopeClaimMapping, allClaims []map[string]interface{}) ([]*Scope, error) {
+	pruned, err := c.Repository.PruneUnclaimedScopes(ctx, mapping, allClaims)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range pruned {
+		c.invalidateScopeDeleted(s.PublicId, s.ParentId)
+	}
+	return pruned, nil
+}
+
+// ImportScopeTree behaves like Repository.ImportScopeTree, invalidating
+// the org/project listings for every scope it creates.
+func (c *CachedRepository) ImportScopeTree(ctx context.Context, data []byte, newParentId, userId string, opt ...Option) (*ScopeNode, error) {
+	root, err := c.Repository.ImportScopeTree(ctx, data, newParentId, userId, opt...)
+	if err != nil {
+		return nil, err
+	}
+	c.invalidateImportedTree(root, newParentId)
+	return root, nil
+}
+
+// invalidateImportedTree walks a tree ImportScopeTree just created,
+// invalidating the org/project listing each node's parent would appear
+// in.
+func (c *CachedRepository) invalidateImportedTree(node *ScopeNode, parentId string) {
+	if node == nil {
+		return
+	}
+	c.invalidateScopeCreated(parentId)
+	for _, child := range node.Children {
+		c.invalidateImportedTree(child, node.Scope.PublicId)
+	}
+}
@@ -0,0 +1,145 @@
+package iam
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScopeCache_GetSetMiss(t *testing.T) {
+	c := newScopeCache(time.Minute, 10)
+
+	if _, ok := c.get("scope", "o_1234567890"); ok {
+		t.Fatal("get on empty cache should miss")
+	}
+
+	c.set("scope", "o_1234567890", 1, "value")
+	v, ok := c.get("scope", "o_1234567890")
+	if !ok {
+		t.Fatal("get after set should hit")
+	}
+	if v.(string) != "value" {
+		t.Fatalf("get returned %v, want %q", v, "value")
+	}
+}
+
+func TestScopeCache_TTLExpiry(t *testing.T) {
+	c := newScopeCache(time.Millisecond, 10)
+	c.set("scope", "o_1234567890", 1, "value")
+
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := c.get("scope", "o_1234567890"); ok {
+		t.Fatal("get after ttl expiry should miss")
+	}
+}
+
+func TestScopeCache_Invalidate(t *testing.T) {
+	c := newScopeCache(time.Minute, 10)
+	c.set("scope", "o_1234567890", 1, "value")
+
+	c.invalidate("scope", "o_1234567890")
+
+	if _, ok := c.get("scope", "o_1234567890"); ok {
+		t.Fatal("get after invalidate should miss")
+	}
+
+	// Invalidating a key that was never set must be a no-op, not a panic.
+	c.invalidate("scope", "o_does_not_exist")
+}
+
+func TestScopeCache_CapacityEviction(t *testing.T) {
+	c := newScopeCache(time.Minute, 2)
+	c.set("scope", "o_1", 1, "a")
+	c.set("scope", "o_2", 1, "b")
+	c.set("scope", "o_3", 1, "c")
+
+	if len(c.entries) != 2 {
+		t.Fatalf("cache has %d entries, want at most maxEntries (2)", len(c.entries))
+	}
+	if _, ok := c.get("scope", "o_3"); !ok {
+		t.Fatal("most recently set entry should not have been evicted")
+	}
+}
+
+// TestCachedRepository_invalidateScopeCreated verifies CreateScope's
+// invalidation is reproduced exactly by the helper the CreateScopes,
+// SyncScopesFromClaims, and ImportScopeTree overrides use, since those
+// methods are defined on *Repository and never dispatch through
+// CachedRepository.CreateScope.
+func TestCachedRepository_invalidateScopeCreated(t *testing.T) {
+	c := &CachedRepository{cache: newScopeCache(time.Minute, 10)}
+	c.cache.set("orgs", "orgs", 0, []*Scope{})
+	c.cache.set("projects", "projects:o_1234567890", 0, []*Scope{})
+
+	c.invalidateScopeCreated("o_1234567890")
+
+	if _, ok := c.cache.get("orgs", "orgs"); ok {
+		t.Error("invalidateScopeCreated should invalidate the cached org listing")
+	}
+	if _, ok := c.cache.get("projects", "projects:o_1234567890"); ok {
+		t.Error("invalidateScopeCreated should invalidate the parent's cached project listing")
+	}
+}
+
+// TestCachedRepository_invalidateScopeCreated_noParent verifies that an
+// empty parentId (e.g. a new org) still invalidates the org listing.
+func TestCachedRepository_invalidateScopeCreated_noParent(t *testing.T) {
+	c := &CachedRepository{cache: newScopeCache(time.Minute, 10)}
+	c.cache.set("orgs", "orgs", 0, []*Scope{})
+
+	c.invalidateScopeCreated("")
+
+	if _, ok := c.cache.get("orgs", "orgs"); ok {
+		t.Error("invalidateScopeCreated should invalidate the cached org listing even with no parent")
+	}
+}
+
+// TestCachedRepository_invalidateScopeDeleted verifies the helper
+// PruneUnclaimedScopes's override uses to mirror DeleteScope's own
+// invalidation for every scope it deletes.
+func TestCachedRepository_invalidateScopeDeleted(t *testing.T) {
+	c := &CachedRepository{cache: newScopeCache(time.Minute, 10)}
+	c.cache.set("scope", "p_1234567890", 1, &Scope{})
+	c.cache.set("grants", "p_1234567890", 0, []string{"id=*;type=*;actions=*"})
+	c.cache.set("orgs", "orgs", 0, []*Scope{})
+	c.cache.set("projects", "projects:o_1234567890", 0, []*Scope{})
+
+	c.invalidateScopeDeleted("p_1234567890", "o_1234567890")
+
+	for _, tc := range []struct{ kind, key string }{
+		{"scope", "p_1234567890"},
+		{"grants", "p_1234567890"},
+		{"orgs", "orgs"},
+		{"projects", "projects:o_1234567890"},
+	} {
+		if _, ok := c.cache.get(tc.kind, tc.key); ok {
+			t.Errorf("invalidateScopeDeleted should have invalidated (%s, %s)", tc.kind, tc.key)
+		}
+	}
+}
+
+// TestCachedRepository_invalidateImportedTree verifies ImportScopeTree's
+// override walks every node of the newly created tree, invalidating the
+// org/project listing each node's parent would appear in -- not just the
+// root's.
+func TestCachedRepository_invalidateImportedTree(t *testing.T) {
+	c := &CachedRepository{cache: newScopeCache(time.Minute, 10)}
+	c.cache.set("projects", "projects:o_1234567890", 0, []*Scope{})
+	c.cache.set("projects", "projects:p_child0000", 0, []*Scope{})
+
+	root := &ScopeNode{
+		Scope: &Scope{PublicId: "p_child0000"},
+		Children: []*ScopeNode{
+			{Scope: &Scope{PublicId: "p_grandchild"}},
+		},
+	}
+
+	c.invalidateImportedTree(root, "o_1234567890")
+
+	if _, ok := c.cache.get("projects", "projects:o_1234567890"); ok {
+		t.Error("invalidateImportedTree should invalidate the root's parent's project listing")
+	}
+	if _, ok := c.cache.get("projects", "projects:p_child0000"); ok {
+		t.Error("invalidateImportedTree should invalidate the child's parent's project listing")
+	}
+}
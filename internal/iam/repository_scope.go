@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/hashicorp/boundary/internal/db"
@@ -13,12 +14,32 @@ import (
 	"github.com/hashicorp/boundary/internal/oplog"
 	"github.com/hashicorp/boundary/internal/types/resource"
 	"github.com/hashicorp/boundary/internal/types/scope"
-	wrapping "github.com/hashicorp/go-kms-wrapping"
 )
 
-// CreateScope will create a scope in the repository and return the written
-// scope. Supported options include: WithPublicId and WithRandomReader.
-func (r *Repository) CreateScope(ctx context.Context, s *Scope, userId string, opt ...Option) (*Scope, error) {
+// scopeCreatePlan holds everything CreateScope/CreateScopes can compute
+// before opening a transaction: the scope row to insert, its oplog
+// metadata, the KMS material needed to create its keys, and the role
+// instances its templates provision, along with the creating user. The
+// parent's oplog wrapper is not part of the plan: writeScopeCreate resolves
+// it inside the transaction.
+type scopeCreatePlan struct {
+	scopePublicId    string
+	scopeRaw         interface{}
+	scopeMetadata    oplog.Metadata
+	parentId         string
+	userId           string
+	externalWrappers *kms.ExternalWrappers
+	reader           io.Reader
+	roleInstances    []*scopeRoleInstance
+}
+
+// prepareScopeCreate validates s and does all of the
+// non-transactional work CreateScope needs (public id generation, oplog
+// metadata, and role template instantiation), returning a plan that
+// writeScopeCreate can execute inside a DoTx. Splitting this out lets
+// CreateScopes share a single transaction across many scopes while still
+// failing fast, before any writes, if one of them is invalid.
+func (r *Repository) prepareScopeCreate(ctx context.Context, s *Scope, userId string, opt ...Option) (*scopeCreatePlan, error) {
 	if s == nil {
 		return nil, fmt.Errorf("create scope: missing scope %w", errors.ErrInvalidParameter)
 	}
@@ -29,34 +50,25 @@ func (r *Repository) CreateScope(ctx context.Context, s *Scope, userId string, o
 		return nil, fmt.Errorf("create scope: public id not empty: %w", errors.ErrInvalidParameter)
 	}
 
-	var parentOplogWrapper wrapping.Wrapper
 	var externalWrappers *kms.ExternalWrappers
-	var err error
 	switch s.Type {
 	case scope.Unknown.String():
 		return nil, fmt.Errorf("create scope: unknown type: %w", errors.ErrInvalidParameter)
 	case scope.Global.String():
 		return nil, fmt.Errorf("create scope: invalid type: %w", errors.ErrInvalidParameter)
 	default:
-		switch s.ParentId {
-		case "":
+		if s.ParentId == "" {
 			return nil, fmt.Errorf("create scope: missing parent id: %w", errors.ErrInvalidParameter)
-		case scope.Global.String():
-			parentOplogWrapper, err = r.kms.GetWrapper(ctx, scope.Global.String(), kms.KeyPurposeOplog)
-		default:
-			parentOplogWrapper, err = r.kms.GetWrapper(ctx, s.ParentId, kms.KeyPurposeOplog)
 		}
 		externalWrappers = r.kms.GetExternalWrappers()
 	}
-	if err != nil {
-		return nil, fmt.Errorf("create scope: unable to get oplog wrapper: %w", err)
-	}
 
 	opts := getOpts(opt...)
 
 	var scopePublicId string
 	var scopeMetadata oplog.Metadata
 	var scopeRaw interface{}
+	var err error
 	{
 		scopeType := scope.Map[s.Type]
 		if opts.withPublicId != "" {
@@ -80,71 +92,40 @@ func (r *Repository) CreateScope(ctx context.Context, s *Scope, userId string, o
 		scopeMetadata["op-type"] = []string{oplog.OpType_OP_TYPE_CREATE.String()}
 	}
 
-	var adminRolePublicId string
-	var adminRoleMetadata oplog.Metadata
-	var adminRole *Role
-	var adminRoleRaw interface{}
-	switch {
-	case userId == "",
-		userId == "u_anon",
-		userId == "u_auth",
-		userId == "u_recovery",
-		opts.withSkipAdminRoleCreation:
-		// TODO: Cause a log entry. The repo doesn't have a logger right now,
-		// and ideally we will be using context to pass around log info scoped
-		// to this request for grouped display in the server log. The only
-		// reason this should ever happen anyways is via the administrative
-		// recovery workflow so it's already a special case.
-
-		// Also, stop linter from complaining
-		_ = adminRole
-
-	default:
-		adminRole, err = NewRole(scopePublicId)
-		if err != nil {
-			return nil, fmt.Errorf("create scope: error instantiating new admin role: %w", err)
-		}
-		adminRolePublicId, err = newRoleId()
-		if err != nil {
-			return nil, fmt.Errorf("create scope: error generating public id for new admin role: %w", err)
-		}
-		adminRole.PublicId = adminRolePublicId
-		adminRole.Name = "Administration"
-		adminRole.Description = fmt.Sprintf("Role created for administration of scope %s by user %s at its creation time", scopePublicId, userId)
-		adminRoleRaw = adminRole
-		adminRoleMetadata = oplog.Metadata{
-			"resource-public-id": []string{adminRolePublicId},
-			"scope-id":           []string{scopePublicId},
-			"scope-type":         []string{s.Type},
-			"resource-type":      []string{resource.Role.String()},
-			"op-type":            []string{oplog.OpType_OP_TYPE_CREATE.String()},
-		}
-	}
-
-	var defaultRolePublicId string
-	var defaultRoleMetadata oplog.Metadata
-	var defaultRole *Role
-	var defaultRoleRaw interface{}
-	if !opts.withSkipDefaultRoleCreation && s.Type == scope.Org.String() {
-		defaultRole, err = NewRole(scopePublicId)
+	// Rather than hard-coding the admin/default roles, build one role
+	// instance per applicable RoleTemplate (the repository's registered
+	// templates, or the built-in Administration/Login and Default Grants
+	// pair if none are registered), so operators can extend or override the
+	// defaults provisioned on every new scope.
+	var roleInstances []*scopeRoleInstance
+	for _, tmpl := range r.roleTemplatesFor(s, userId, opts) {
+		role, err := NewRole(scopePublicId)
 		if err != nil {
-			return nil, fmt.Errorf("create scope: error instantiating new default role: %w", err)
+			return nil, fmt.Errorf("create scope: error instantiating new role for template %q: %w", tmpl.Name, err)
 		}
-		defaultRolePublicId, err = newRoleId()
+		rolePublicId, err := newRoleId()
 		if err != nil {
-			return nil, fmt.Errorf("create scope: error generating public id for new default role: %w", err)
+			return nil, fmt.Errorf("create scope: error generating public id for role template %q: %w", tmpl.Name, err)
 		}
-		defaultRole.PublicId = defaultRolePublicId
-		defaultRole.Name = "Login and Default Grants"
-		defaultRole.Description = fmt.Sprintf("Role created for login capability and account self-management for users of scope %s at its creation time", scopePublicId)
-		defaultRoleRaw = defaultRole
-		defaultRoleMetadata = oplog.Metadata{
-			"resource-public-id": []string{defaultRolePublicId},
-			"scope-id":           []string{scopePublicId},
-			"scope-type":         []string{s.Type},
-			"resource-type":      []string{resource.Role.String()},
-			"op-type":            []string{oplog.OpType_OP_TYPE_CREATE.String()},
+		role.PublicId = rolePublicId
+		role.Name = tmpl.Name
+		if tmpl.AssignCreatingUser {
+			role.Description = fmt.Sprintf(tmpl.Description, scopePublicId, userId)
+		} else {
+			role.Description = fmt.Sprintf(tmpl.Description, scopePublicId)
 		}
+		roleInstances = append(roleInstances, &scopeRoleInstance{
+			tmpl:         tmpl,
+			role:         role,
+			rolePublicId: rolePublicId,
+			metadata: oplog.Metadata{
+				"resource-public-id": []string{rolePublicId},
+				"scope-id":           []string{scopePublicId},
+				"scope-type":         []string{s.Type},
+				"resource-type":      []string{resource.Role.String()},
+				"op-type":            []string{oplog.OpType_OP_TYPE_CREATE.String()},
+			},
+		})
 	}
 
 	reader := opts.withRandomReader
@@ -152,195 +133,221 @@ func (r *Repository) CreateScope(ctx context.Context, s *Scope, userId string, o
 		reader = rand.Reader
 	}
 
-	_, err = r.writer.DoTx(
-		ctx,
-		db.StdRetryCnt,
-		db.ExpBackoff{},
-		func(dbr db.Reader, w db.Writer) error {
-			if err := w.Create(
-				ctx,
-				scopeRaw,
-				db.WithOplog(parentOplogWrapper, scopeMetadata),
-			); err != nil {
-				return fmt.Errorf("error creating scope: %w", err)
-			}
+	return &scopeCreatePlan{
+		scopePublicId:    scopePublicId,
+		scopeRaw:         scopeRaw,
+		scopeMetadata:    scopeMetadata,
+		parentId:         s.ParentId,
+		userId:           userId,
+		externalWrappers: externalWrappers,
+		reader:           reader,
+		roleInstances:    roleInstances,
+	}, nil
+}
 
-			s := scopeRaw.(*Scope)
+// writeScopeCreate performs the actual inserts for plan -- the scope row,
+// its keys, and its role templates' roles/grants/principals -- against the
+// given transaction. It's shared by CreateScope (one plan, one
+// transaction) and CreateScopes (many plans, one shared transaction).
+//
+// The parent's oplog wrapper is resolved here, against the given
+// transaction, rather than up front in prepareScopeCreate, through kmsRepo
+// (backed by dbr/w) rather than r.kms's own cache.
+func (r *Repository) writeScopeCreate(ctx context.Context, dbr db.Reader, w db.Writer, plan *scopeCreatePlan) error {
+	kmsRepo, err := kms.NewRepository(dbr, w)
+	if err != nil {
+		return fmt.Errorf("error creating new kms repo: %w", err)
+	}
+	parentOplogWrapper, err := r.kms.GetWrapper(ctx, plan.parentId, kms.KeyPurposeOplog, kms.WithRepository(kmsRepo))
+	if err != nil {
+		return fmt.Errorf("error fetching parent oplog wrapper: %w", err)
+	}
 
-			// Create the scope's keys
-			_, err = kms.CreateKeysTx(ctx, dbr, w, externalWrappers.Root(), reader, s.PublicId)
-			if err != nil {
-				return fmt.Errorf("error creating scope keys: %w", err)
-			}
+	if err := w.Create(
+		ctx,
+		plan.scopeRaw,
+		db.WithOplog(parentOplogWrapper, plan.scopeMetadata),
+	); err != nil {
+		return fmt.Errorf("error creating scope: %w", err)
+	}
 
-			kmsRepo, err := kms.NewRepository(dbr, w)
-			if err != nil {
-				return fmt.Errorf("error creating new kms repo: %w", err)
-			}
-			childOplogWrapper, err := r.kms.GetWrapper(ctx, s.PublicId, kms.KeyPurposeOplog, kms.WithRepository(kmsRepo))
-			if err != nil {
-				return fmt.Errorf("error fetching new scope oplog wrapper: %w", err)
-			}
+	s := plan.scopeRaw.(*Scope)
 
-			// We create a new role, then set grants and principals on it. This
-			// turns into a bunch of stuff sadly because the role is the
-			// aggregate.
-			if adminRoleRaw != nil {
-				if err := w.Create(
-					ctx,
-					adminRoleRaw,
-					db.WithOplog(childOplogWrapper, adminRoleMetadata),
-				); err != nil {
-					return fmt.Errorf("error creating role: %w", err)
-				}
+	// Create the scope's keys
+	if _, err := kms.CreateKeysTx(ctx, dbr, w, plan.externalWrappers.Root(), plan.reader, s.PublicId); err != nil {
+		return fmt.Errorf("error creating scope keys: %w", err)
+	}
 
-				adminRole = adminRoleRaw.(*Role)
+	childOplogWrapper, err := r.kms.GetWrapper(ctx, s.PublicId, kms.KeyPurposeOplog, kms.WithRepository(kmsRepo))
+	if err != nil {
+		return fmt.Errorf("error fetching new scope oplog wrapper: %w", err)
+	}
 
-				msgs := make([]*oplog.Message, 0, 3)
-				roleTicket, err := w.GetTicket(adminRole)
-				if err != nil {
-					return fmt.Errorf("unable to get ticket: %w", err)
-				}
+	// For each applicable role template we create a new role, then set
+	// grants and principals on it. This turns into a bunch of stuff sadly
+	// because the role is the aggregate.
+	for _, ri := range plan.roleInstances {
+		if err := w.Create(
+			ctx,
+			ri.role,
+			db.WithOplog(childOplogWrapper, ri.metadata),
+		); err != nil {
+			return fmt.Errorf("error creating role: %w", err)
+		}
 
-				// We need to update the role version as that's the aggregate
-				var roleOplogMsg oplog.Message
-				rowsUpdated, err := w.Update(ctx, adminRole, []string{"Version"}, nil, db.NewOplogMsg(&roleOplogMsg), db.WithVersion(&adminRole.Version))
-				if err != nil {
-					return fmt.Errorf("unable to update role version for adding grant: %w", err)
-				}
-				if rowsUpdated != 1 {
-					return fmt.Errorf("updated role but %d rows updated", rowsUpdated)
-				}
+		msgs := make([]*oplog.Message, 0, 2+len(ri.tmpl.Grants))
+		roleTicket, err := w.GetTicket(ri.role)
+		if err != nil {
+			return fmt.Errorf("unable to get ticket: %w", err)
+		}
 
-				msgs = append(msgs, &roleOplogMsg)
+		// We need to update the role version as that's the aggregate
+		var roleOplogMsg oplog.Message
+		rowsUpdated, err := w.Update(ctx, ri.role, []string{"Version"}, nil, db.NewOplogMsg(&roleOplogMsg), db.WithVersion(&ri.role.Version))
+		if err != nil {
+			return fmt.Errorf("unable to update role version for adding grant: %w", err)
+		}
+		if rowsUpdated != 1 {
+			return fmt.Errorf("updated role but %d rows updated", rowsUpdated)
+		}
+		msgs = append(msgs, &roleOplogMsg)
 
-				roleGrant, err := NewRoleGrant(adminRolePublicId, "id=*;type=*;actions=*")
+		// Grants
+		{
+			grants := make([]interface{}, 0, len(ri.tmpl.Grants))
+			for _, g := range ri.tmpl.Grants {
+				roleGrant, err := NewRoleGrant(ri.rolePublicId, g)
 				if err != nil {
 					return fmt.Errorf("unable to create in memory role grant: %w", err)
 				}
-				roleGrantOplogMsgs := make([]*oplog.Message, 0, 1)
-				if err := w.CreateItems(ctx, []interface{}{roleGrant}, db.NewOplogMsgs(&roleGrantOplogMsgs)); err != nil {
-					return fmt.Errorf("unable to add grants: %w", err)
-				}
-				msgs = append(msgs, roleGrantOplogMsgs...)
+				grants = append(grants, roleGrant)
+			}
+			roleGrantOplogMsgs := make([]*oplog.Message, 0, len(grants))
+			if err := w.CreateItems(ctx, grants, db.NewOplogMsgs(&roleGrantOplogMsgs)); err != nil {
+				return fmt.Errorf("unable to add grants: %w", err)
+			}
+			msgs = append(msgs, roleGrantOplogMsgs...)
+		}
 
-				rolePrincipal, err := NewUserRole(adminRolePublicId, userId)
+		// Principals
+		principalIds := ri.tmpl.Principals
+		if len(principalIds) == 0 && ri.tmpl.AssignCreatingUser {
+			principalIds = []string{plan.userId}
+		}
+		if len(principalIds) > 0 {
+			principals := make([]interface{}, 0, len(principalIds))
+			for _, p := range principalIds {
+				rolePrincipal, err := NewUserRole(ri.rolePublicId, p)
 				if err != nil {
 					return fmt.Errorf("unable to create in memory role user: %w", err)
 				}
-				roleUserOplogMsgs := make([]*oplog.Message, 0, 1)
-				if err := w.CreateItems(ctx, []interface{}{rolePrincipal}, db.NewOplogMsgs(&roleUserOplogMsgs)); err != nil {
-					return fmt.Errorf("unable to add grants: %w", err)
-				}
-				msgs = append(msgs, roleUserOplogMsgs...)
-
-				metadata := oplog.Metadata{
-					"op-type":            []string{oplog.OpType_OP_TYPE_CREATE.String()},
-					"scope-id":           []string{s.PublicId},
-					"scope-type":         []string{s.Type},
-					"resource-public-id": []string{adminRole.PublicId},
-				}
-				if err := w.WriteOplogEntryWith(ctx, childOplogWrapper, roleTicket, metadata, msgs); err != nil {
-					return fmt.Errorf("unable to write oplog: %w", err)
-				}
+				principals = append(principals, rolePrincipal)
 			}
+			roleUserOplogMsgs := make([]*oplog.Message, 0, len(principals))
+			if err := w.CreateItems(ctx, principals, db.NewOplogMsgs(&roleUserOplogMsgs)); err != nil {
+				return fmt.Errorf("unable to add grants: %w", err)
+			}
+			msgs = append(msgs, roleUserOplogMsgs...)
+		}
 
-			// We create a new role, then set grants and principals on it. This
-			// turns into a bunch of stuff sadly because the role is the
-			// aggregate.
-			if defaultRoleRaw != nil {
-				if err := w.Create(
-					ctx,
-					defaultRoleRaw,
-					db.WithOplog(childOplogWrapper, defaultRoleMetadata),
-				); err != nil {
-					return fmt.Errorf("error creating role: %w", err)
-				}
+		metadata := oplog.Metadata{
+			"op-type":            []string{oplog.OpType_OP_TYPE_CREATE.String()},
+			"scope-id":           []string{s.PublicId},
+			"scope-type":         []string{s.Type},
+			"resource-public-id": []string{ri.role.PublicId},
+		}
+		if err := w.WriteOplogEntryWith(ctx, childOplogWrapper, roleTicket, metadata, msgs); err != nil {
+			return fmt.Errorf("unable to write oplog: %w", err)
+		}
+	}
 
-				defaultRole = defaultRoleRaw.(*Role)
+	return nil
+}
 
-				msgs := make([]*oplog.Message, 0, 6)
-				roleTicket, err := w.GetTicket(defaultRole)
-				if err != nil {
-					return fmt.Errorf("unable to get ticket: %w", err)
-				}
+// CreateScope will create a scope in the repository and return the written
+// scope. Supported options include: WithPublicId, WithRandomReader, and
+// WithRoleTemplates, which overrides the set of RoleTemplates used to
+// provision the scope's default roles for this call (see
+// Repository.RegisterRoleTemplate to change the defaults for every call).
+func (r *Repository) CreateScope(ctx context.Context, s *Scope, userId string, opt ...Option) (*Scope, error) {
+	plan, err := r.prepareScopeCreate(ctx, s, userId, opt...)
+	if err != nil {
+		return nil, err
+	}
 
-				// We need to update the role version as that's the aggregate
-				var roleOplogMsg oplog.Message
-				rowsUpdated, err := w.Update(ctx, defaultRole, []string{"Version"}, nil, db.NewOplogMsg(&roleOplogMsg), db.WithVersion(&defaultRole.Version))
-				if err != nil {
-					return fmt.Errorf("unable to update role version for adding grant: %w", err)
-				}
-				if rowsUpdated != 1 {
-					return fmt.Errorf("updated role but %d rows updated", rowsUpdated)
-				}
-				msgs = append(msgs, &roleOplogMsg)
-
-				// Grants
-				{
-					grants := []interface{}{}
-					roleGrant, err := NewRoleGrant(defaultRolePublicId, "type=scope;actions=list")
-					if err != nil {
-						return fmt.Errorf("unable to create in memory role grant: %w", err)
-					}
-					grants = append(grants, roleGrant)
-
-					roleGrant, err = NewRoleGrant(defaultRolePublicId, "id=*;type=auth-method;actions=authenticate,list")
-					if err != nil {
-						return fmt.Errorf("unable to create in memory role grant: %w", err)
-					}
-					grants = append(grants, roleGrant)
-					roleGrant, err = NewRoleGrant(defaultRolePublicId, "id={{account.id}};actions=read,change-password")
-					if err != nil {
-						return fmt.Errorf("unable to create in memory role grant: %w", err)
-					}
-					grants = append(grants, roleGrant)
-
-					roleGrantOplogMsgs := make([]*oplog.Message, 0, 3)
-					if err := w.CreateItems(ctx, grants, db.NewOplogMsgs(&roleGrantOplogMsgs)); err != nil {
-						return fmt.Errorf("unable to add grants: %w", err)
-					}
-					msgs = append(msgs, roleGrantOplogMsgs...)
-				}
+	_, err = r.writer.DoTx(
+		ctx,
+		db.StdRetryCnt,
+		db.ExpBackoff{},
+		func(dbr db.Reader, w db.Writer) error {
+			return r.writeScopeCreate(ctx, dbr, w, plan)
+		},
+	)
+	if err != nil {
+		if errors.IsUniqueError(err) {
+			return nil, fmt.Errorf("create scope: scope %s/%s already exists: %w", plan.scopePublicId, s.Name, errors.ErrNotUnique)
+		}
+		return nil, fmt.Errorf("create scope: id %s got error: %w", plan.scopePublicId, err)
+	}
+	return plan.scopeRaw.(*Scope), nil
+}
 
-				// Principals
-				{
-					principals := []interface{}{}
-					rolePrincipal, err := NewUserRole(defaultRolePublicId, "u_anon")
-					if err != nil {
-						return fmt.Errorf("unable to create in memory role user: %w", err)
-					}
-					principals = append(principals, rolePrincipal)
-
-					roleUserOplogMsgs := make([]*oplog.Message, 0, 2)
-					if err := w.CreateItems(ctx, principals, db.NewOplogMsgs(&roleUserOplogMsgs)); err != nil {
-						return fmt.Errorf("unable to add grants: %w", err)
-					}
-					msgs = append(msgs, roleUserOplogMsgs...)
-				}
+// CreateScopes provisions many scopes (and their templated roles) inside a
+// single transaction. This is intended for bulk tenant bootstrapping (N
+// projects under an existing org), where opening a transaction per scope
+// would multiply both wall-clock time and DB round-trips. Only the
+// transaction is shared: each scope and each of its roles is written the
+// same way CreateScope writes it, with its own ticket and oplog entry, so
+// the number of oplog writes per scope is the same as with CreateScope. If
+// any scope fails -- a uniqueness violation, a KMS wrapping failure,
+// whatever -- every scope in the batch is rolled back.
+//
+// Every scope's ParentId must name a scope that already exists. Public ids
+// are generated by this call (a scope with PublicId set is rejected, and
+// WithPublicId gives the same id to every scope in the call), so a scope
+// in scopes cannot name another scope of the same batch as its parent.
+//
+// Every scope shares userId for the purposes of role templates that assign
+// the creating user (e.g. the built-in Administration role).
+func (r *Repository) CreateScopes(ctx context.Context, scopes []*Scope, userId string, opt ...Option) ([]*Scope, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("create scopes: missing scopes: %w", errors.ErrInvalidParameter)
+	}
 
-				metadata := oplog.Metadata{
-					"op-type":            []string{oplog.OpType_OP_TYPE_CREATE.String()},
-					"scope-id":           []string{s.PublicId},
-					"scope-type":         []string{s.Type},
-					"resource-public-id": []string{defaultRole.PublicId},
-				}
-				if err := w.WriteOplogEntryWith(ctx, childOplogWrapper, roleTicket, metadata, msgs); err != nil {
-					return fmt.Errorf("unable to write oplog: %w", err)
+	plans := make([]*scopeCreatePlan, 0, len(scopes))
+	for i, s := range scopes {
+		plan, err := r.prepareScopeCreate(ctx, s, userId, opt...)
+		if err != nil {
+			return nil, fmt.Errorf("create scopes: scope %d: %w", i, err)
+		}
+		plans = append(plans, plan)
+	}
+
+	_, err := r.writer.DoTx(
+		ctx,
+		db.StdRetryCnt,
+		db.ExpBackoff{},
+		func(dbr db.Reader, w db.Writer) error {
+			for _, plan := range plans {
+				if err := r.writeScopeCreate(ctx, dbr, w, plan); err != nil {
+					return err
 				}
 			}
-
 			return nil
 		},
 	)
-
 	if err != nil {
 		if errors.IsUniqueError(err) {
-			return nil, fmt.Errorf("create scope: scope %s/%s already exists: %w", scopePublicId, s.Name, errors.ErrNotUnique)
+			return nil, fmt.Errorf("create scopes: a scope in the batch already exists: %w", errors.ErrNotUnique)
 		}
-		return nil, fmt.Errorf("create scope: id %s got error: %w", scopePublicId, err)
+		return nil, fmt.Errorf("create scopes: got error: %w", err)
+	}
+
+	created := make([]*Scope, 0, len(plans))
+	for _, plan := range plans {
+		created = append(created, plan.scopeRaw.(*Scope))
 	}
-	return scopeRaw.(*Scope), nil
+	return created, nil
 }
 
 // UpdateScope will update a scope in the repository and return the written
@@ -357,7 +364,7 @@ func (r *Repository) UpdateScope(ctx context.Context, scope *Scope, version uint
 		return nil, db.NoRowsAffected, fmt.Errorf("update scope: missing public id: %w", errors.ErrInvalidParameter)
 	}
 	if contains(fieldMaskPaths, "ParentId") {
-		return nil, db.NoRowsAffected, fmt.Errorf("update scope: you cannot change a scope's parent: %w", errors.ErrInvalidFieldMask)
+		return nil, db.NoRowsAffected, fmt.Errorf("update scope: you cannot change a scope's parent with UpdateScope, use MoveScope instead: %w", errors.ErrInvalidFieldMask)
 	}
 	var dbMask, nullFields []string
 	dbMask, nullFields = dbcommon.BuildUpdatePaths(
@@ -0,0 +1,188 @@
+package iam
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/hashicorp/boundary/internal/db"
+	"github.com/hashicorp/boundary/internal/errors"
+	"github.com/hashicorp/boundary/internal/kms"
+	"github.com/hashicorp/boundary/internal/oplog"
+	"github.com/hashicorp/boundary/internal/types/resource"
+	"github.com/hashicorp/boundary/internal/types/scope"
+)
+
+// MoveScope atomically reparents a project, moving it from its current org
+// to newParentId. Reparenting orgs is not supported; the global scope is
+// always an org's parent. Supported options include: WithRandomReader and
+// WithSeverOldParentPrincipals.
+//
+// The move re-derives the project's oplog wrapper chain and re-wraps its
+// per-scope keys under the new parent's root wrapper. The move's own
+// oplog entry is wrapped and written with the new parent's oplog wrapper,
+// consistent with the "scope-id" metadata field it's recorded under
+// (old-scope-id is also recorded, for audit purposes, but any replay path
+// that resolves its decryption wrapper from "scope-id" must use the new
+// parent's wrapper, the same one this write used).
+func (r *Repository) MoveScope(ctx context.Context, publicId, newParentId string, version uint32, opt ...Option) (*Scope, error) {
+	if publicId == "" {
+		return nil, fmt.Errorf("move scope: missing public id: %w", errors.ErrInvalidParameter)
+	}
+	if newParentId == "" {
+		return nil, fmt.Errorf("move scope: missing new parent id: %w", errors.ErrInvalidParameter)
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("move scope: missing version: %w", errors.ErrInvalidParameter)
+	}
+	if publicId == newParentId {
+		return nil, fmt.Errorf("move scope: a scope cannot be its own parent: %w", errors.ErrInvalidParameter)
+	}
+
+	s, err := r.LookupScope(ctx, publicId)
+	if err != nil {
+		return nil, fmt.Errorf("move scope: %w", err)
+	}
+	if s == nil {
+		return nil, fmt.Errorf("move scope: %s: %w", publicId, errors.ErrRecordNotFound)
+	}
+	if s.Type != scope.Project.String() {
+		return nil, fmt.Errorf("move scope: reparenting a %s is not supported, only projects can be moved: %w", s.Type, errors.ErrInvalidParameter)
+	}
+	if s.ParentId == newParentId {
+		return nil, fmt.Errorf("move scope: %s is already a child of %s: %w", publicId, newParentId, errors.ErrInvalidParameter)
+	}
+
+	newParent, err := r.LookupScope(ctx, newParentId)
+	if err != nil {
+		return nil, fmt.Errorf("move scope: %w", err)
+	}
+	if newParent == nil {
+		return nil, fmt.Errorf("move scope: new parent %s: %w", newParentId, errors.ErrRecordNotFound)
+	}
+	if newParent.Type != scope.Org.String() {
+		// A project's parent is always an org, and an org's parent is
+		// always global, so this is also what rules out any cycle: a
+		// project can never be an ancestor of the org it would be
+		// reparented under.
+		return nil, fmt.Errorf("move scope: new parent %s is a %s, not an org: %w", newParentId, newParent.Type, errors.ErrInvalidParameter)
+	}
+
+	oldParentId := s.ParentId
+
+	newParentOplogWrapper, err := r.kms.GetWrapper(ctx, newParentId, kms.KeyPurposeOplog)
+	if err != nil {
+		return nil, fmt.Errorf("move scope: unable to get new parent oplog wrapper: %w", err)
+	}
+	// Resolved up front, before any writes, so the move fails fast if the
+	// old parent's wrapper chain can't be derived, rather than leaving the
+	// scope half-moved.
+	if _, err := r.kms.GetWrapper(ctx, oldParentId, kms.KeyPurposeOplog); err != nil {
+		return nil, fmt.Errorf("move scope: unable to get old parent oplog wrapper: %w", err)
+	}
+	oldRootWrapper, err := r.kms.GetWrapper(ctx, oldParentId, kms.KeyPurposeRootKey)
+	if err != nil {
+		return nil, fmt.Errorf("move scope: unable to get old parent root wrapper: %w", err)
+	}
+	newRootWrapper, err := r.kms.GetWrapper(ctx, newParentId, kms.KeyPurposeRootKey)
+	if err != nil {
+		return nil, fmt.Errorf("move scope: unable to get new parent root wrapper: %w", err)
+	}
+
+	opts := getOpts(opt...)
+	reader := opts.withRandomReader
+	if reader == nil {
+		reader = rand.Reader
+	}
+
+	metadata := oplog.Metadata{
+		"resource-public-id": []string{publicId},
+		"scope-id":           []string{newParentId},
+		"old-scope-id":       []string{oldParentId},
+		"scope-type":         []string{s.Type},
+		"resource-type":      []string{resource.Scope.String()},
+		"op-type":            []string{oplog.OpType_OP_TYPE_UPDATE.String()},
+	}
+
+	var returnedScope *Scope
+	_, err = r.writer.DoTx(
+		ctx,
+		db.StdRetryCnt,
+		db.ExpBackoff{},
+		func(dbr db.Reader, w db.Writer) error {
+			updatedScope := allocScope()
+			updatedScope.PublicId = publicId
+			updatedScope.ParentId = newParentId
+
+			rowsUpdated, err := w.Update(
+				ctx,
+				&updatedScope,
+				[]string{"ParentId"},
+				nil,
+				db.WithVersion(&version),
+				db.WithOplog(newParentOplogWrapper, metadata),
+			)
+			if err != nil {
+				return fmt.Errorf("unable to update scope parent: %w", err)
+			}
+			if rowsUpdated != 1 {
+				return fmt.Errorf("updated scope but %d rows updated", rowsUpdated)
+			}
+
+			kmsRepo, err := kms.NewRepository(dbr, w)
+			if err != nil {
+				return fmt.Errorf("unable to create new kms repo: %w", err)
+			}
+			if err := kmsRepo.RewrapKeys(ctx, publicId, oldRootWrapper, newRootWrapper, reader); err != nil {
+				return fmt.Errorf("unable to rewrap scope keys under new parent: %w", err)
+			}
+
+			if opts.withSeverOldParentPrincipals {
+				if err := severOldParentScopeGrants(ctx, w, oldParentId, publicId); err != nil {
+					return fmt.Errorf("unable to sever grants to %s from roles owned by old parent: %w", publicId, err)
+				}
+			}
+
+			returnedScope = &updatedScope
+			return nil
+		},
+	)
+	if err != nil {
+		if errors.IsUniqueError(err) {
+			return nil, fmt.Errorf("move scope: %s already exists under %s: %w", publicId, newParentId, errors.ErrNotUnique)
+		}
+		return nil, fmt.Errorf("move scope: id %s got error: %w", publicId, err)
+	}
+	return returnedScope, nil
+}
+
+// severOldParentScopeGrants removes, from any role owned by oldParentId,
+// only the grant(s) that name scopeId by public id rather than by
+// wildcard. It leaves the role's principal (user/group) assignments and
+// its other grants untouched, so a role that also grants access to
+// unrelated scopes or resources keeps that access and its membership
+// intact -- only the access tied to the scope being moved is severed.
+// Wildcard grants ("id=*;...") are left alone too, since they aren't
+// specific to the scope being moved and removing them would also affect
+// every other scope under oldParentId.
+func severOldParentScopeGrants(ctx context.Context, w db.Writer, oldParentId, scopeId string) error {
+	if _, err := w.Exec(
+		ctx,
+		"delete from iam_role_grant where role_id in (select public_id from iam_role where scope_id = ?) and canonical_grant like ?",
+		[]interface{}{oldParentId, fmt.Sprintf("id=%s;%%", scopeId)},
+	); err != nil {
+		return fmt.Errorf("unable to remove grants naming %s from roles owned by %s: %w", scopeId, oldParentId, err)
+	}
+	return nil
+}
+
+// WithSeverOldParentPrincipals, when passed to MoveScope, removes the
+// grant(s) targeting the moved scope specifically from any role owned by
+// the scope's old parent, rather than preserving them across the move
+// (the default). Other grants on the same role, and its principal
+// assignments, are left untouched.
+func WithSeverOldParentPrincipals() Option {
+	return func(o *options) {
+		o.withSeverOldParentPrincipals = true
+	}
+}
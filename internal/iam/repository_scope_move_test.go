@@ -0,0 +1,67 @@
+package iam
+
+import (
+	"context"
+	"testing"
+)
+
+// TestRepository_MoveScope_validation covers the argument validation
+// MoveScope performs before it ever looks up a scope or touches KMS, so it
+// can run against a zero-value Repository.
+func TestRepository_MoveScope_validation(t *testing.T) {
+	tests := []struct {
+		name        string
+		publicId    string
+		newParentId string
+		version     uint32
+	}{
+		{name: "missing public id", publicId: "", newParentId: "o_1234567890", version: 1},
+		{name: "missing new parent id", publicId: "p_1234567890", newParentId: "", version: 1},
+		{name: "missing version", publicId: "p_1234567890", newParentId: "o_1234567890", version: 0},
+		{name: "scope cannot be its own parent", publicId: "p_1234567890", newParentId: "p_1234567890", version: 1},
+	}
+	r := &Repository{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := r.MoveScope(context.Background(), tt.publicId, tt.newParentId, tt.version); err == nil {
+				t.Fatal("MoveScope should have returned a validation error")
+			}
+		})
+	}
+}
+
+// TestSeverOldParentScopeGrants_likePattern verifies the LIKE pattern used
+// to match a role's canonical grant against the scope being moved, so a
+// grant naming the moved scope specifically is matched while a wildcard
+// grant -- which isn't specific to any one scope -- is not.
+func TestSeverOldParentScopeGrants_likePattern(t *testing.T) {
+	scopeId := "p_1234567890"
+	pattern := "id=" + scopeId + ";%"
+
+	tests := []struct {
+		grant       string
+		wantMatches bool
+	}{
+		{grant: "id=" + scopeId + ";type=target;actions=read", wantMatches: true},
+		{grant: "id=*;type=*;actions=*", wantMatches: false},
+		{grant: "id=p_other0000;type=target;actions=read", wantMatches: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.grant, func(t *testing.T) {
+			if got := sqlLikeMatch(tt.grant, pattern); got != tt.wantMatches {
+				t.Errorf("sqlLikeMatch(%q, %q) = %v, want %v", tt.grant, pattern, got, tt.wantMatches)
+			}
+		})
+	}
+}
+
+// sqlLikeMatch reimplements, for test purposes only, the subset of SQL LIKE
+// semantics severOldParentScopeGrants relies on ("%" as a trailing
+// wildcard) so the pattern it builds can be checked without a database.
+func sqlLikeMatch(s, likePattern string) bool {
+	if len(likePattern) > 0 && likePattern[len(likePattern)-1] == '%' {
+		prefix := likePattern[:len(likePattern)-1]
+		return len(s) >= len(prefix) && s[:len(prefix)] == prefix
+	}
+	return s == likePattern
+}
@@ -0,0 +1,71 @@
+package iam
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/boundary/internal/types/scope"
+)
+
+// TestRepository_prepareScopeCreate_validation covers the validation
+// prepareScopeCreate performs before resolving any KMS wrapper, so it can
+// run against a zero-value Repository (s.Type's default branch would
+// otherwise panic dereferencing a nil r.kms).
+func TestRepository_prepareScopeCreate_validation(t *testing.T) {
+	r := &Repository{}
+
+	t.Run("missing scope", func(t *testing.T) {
+		if _, err := r.prepareScopeCreate(context.Background(), nil, "u_1234567890"); err == nil {
+			t.Fatal("prepareScopeCreate should error on a nil scope")
+		}
+	})
+
+	t.Run("missing scope store", func(t *testing.T) {
+		if _, err := r.prepareScopeCreate(context.Background(), &Scope{}, "u_1234567890"); err == nil {
+			t.Fatal("prepareScopeCreate should error when Scope.Scope is nil")
+		}
+	})
+
+	t.Run("public id not empty", func(t *testing.T) {
+		s, err := NewScope(scope.Org)
+		if err != nil {
+			t.Fatalf("NewScope(scope.Org) error = %v", err)
+		}
+		s.PublicId = "o_1234567890"
+		if _, err := r.prepareScopeCreate(context.Background(), s, "u_1234567890"); err == nil {
+			t.Fatal("prepareScopeCreate should error when the caller already set a public id")
+		}
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		s, err := NewScope(scope.Org)
+		if err != nil {
+			t.Fatalf("NewScope(scope.Org) error = %v", err)
+		}
+		s.Type = scope.Unknown.String()
+		if _, err := r.prepareScopeCreate(context.Background(), s, "u_1234567890"); err == nil {
+			t.Fatal("prepareScopeCreate should error on an unknown scope type")
+		}
+	})
+
+	t.Run("global type is not creatable", func(t *testing.T) {
+		s, err := NewScope(scope.Org)
+		if err != nil {
+			t.Fatalf("NewScope(scope.Org) error = %v", err)
+		}
+		s.Type = scope.Global.String()
+		if _, err := r.prepareScopeCreate(context.Background(), s, "u_1234567890"); err == nil {
+			t.Fatal("prepareScopeCreate should error on the global scope type")
+		}
+	})
+
+	t.Run("project missing parent id", func(t *testing.T) {
+		s, err := NewScope(scope.Project)
+		if err != nil {
+			t.Fatalf("NewScope(scope.Project) error = %v", err)
+		}
+		if _, err := r.prepareScopeCreate(context.Background(), s, "u_1234567890"); err == nil {
+			t.Fatal("prepareScopeCreate should error on a project with no parent id")
+		}
+	})
+}
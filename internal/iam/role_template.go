@@ -0,0 +1,190 @@
+package iam
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/boundary/internal/errors"
+	"github.com/hashicorp/boundary/internal/oplog"
+	"github.com/hashicorp/boundary/internal/types/scope"
+)
+
+// scopeRoleInstance is the in-memory state CreateScope tracks for a single
+// role template while it builds up a new scope: the template it came from,
+// the allocated Role ready to be written, and the oplog metadata for its
+// creation.
+type scopeRoleInstance struct {
+	tmpl         *RoleTemplate
+	role         *Role
+	rolePublicId string
+	metadata     oplog.Metadata
+}
+
+// RoleTemplate describes a role that should be automatically provisioned
+// whenever a scope of a matching type is created via CreateScope. Templates
+// let operators ship their own defaults (for example a "ReadOnly Auditor"
+// role provisioned in every new org) without patching CreateScope itself.
+type RoleTemplate struct {
+	// Name and Description are copied onto the role created from this
+	// template. Description is treated as a fmt.Sprintf format string: it
+	// is formatted with the new scope's public id, and additionally with
+	// the creating user's id if AssignCreatingUser is set.
+	Name        string
+	Description string
+
+	// ScopeTypes restricts which scope types this template applies to. A
+	// nil/empty ScopeTypes applies the template to every scope type
+	// CreateScope supports (currently org and project).
+	ScopeTypes []scope.Type
+
+	// Grants are the grant strings assigned to the role.
+	Grants []string
+
+	// Principals are the public ids of the users/groups assigned to the
+	// role, e.g. "u_anon". If Principals is empty and AssignCreatingUser is
+	// true, the user id passed to CreateScope is assigned instead.
+	Principals []string
+
+	// AssignCreatingUser, when true, assigns the user performing the
+	// CreateScope call to the role (unless Principals is already set) and
+	// causes the template to be skipped when that user is empty or one of
+	// the anonymous/auth/recovery sentinel users.
+	AssignCreatingUser bool
+
+	// isBuiltinAdmin and isBuiltinDefault mark the two templates
+	// defaultRoleTemplates returns, so WithSkipAdminRoleCreation/
+	// WithSkipDefaultRoleCreation can find them by identity rather than by
+	// matching Name -- an operator-registered template is never mistaken
+	// for a built-in just because it happens to reuse one of their names.
+	isBuiltinAdmin   bool
+	isBuiltinDefault bool
+}
+
+// appliesTo reports whether the template should be provisioned for scopes
+// of type st.
+func (t *RoleTemplate) appliesTo(st scope.Type) bool {
+	if len(t.ScopeTypes) == 0 {
+		return true
+	}
+	for _, s := range t.ScopeTypes {
+		if s == st {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *RoleTemplate) validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("role template: missing name: %w", errors.ErrInvalidParameter)
+	}
+	if len(t.Grants) == 0 {
+		return fmt.Errorf("role template: %s: missing grants: %w", t.Name, errors.ErrInvalidParameter)
+	}
+	return nil
+}
+
+// defaultRoleTemplates returns the built-in templates that reproduce
+// CreateScope's historical, hard-coded behavior: an "Administration" role
+// on every org/project, and a "Login and Default Grants" role on every org.
+func defaultRoleTemplates() []*RoleTemplate {
+	return []*RoleTemplate{
+		{
+			Name:               "Administration",
+			Description:        "Role created for administration of scope %s by user %s at its creation time",
+			Grants:             []string{"id=*;type=*;actions=*"},
+			AssignCreatingUser: true,
+			isBuiltinAdmin:     true,
+		},
+		{
+			Name:        "Login and Default Grants",
+			Description: "Role created for login capability and account self-management for users of scope %s at its creation time",
+			ScopeTypes:  []scope.Type{scope.Org},
+			Grants: []string{
+				"type=scope;actions=list",
+				"id=*;type=auth-method;actions=authenticate,list",
+				"id={{account.id}};actions=read,change-password",
+			},
+			Principals:       []string{"u_anon"},
+			isBuiltinDefault: true,
+		},
+	}
+}
+
+// RegisterRoleTemplate adds a role template to the repository's set of
+// default templates, so that every subsequent CreateScope call provisions
+// it for the scope types it applies to. Use WithRoleTemplates on an
+// individual CreateScope call to override the repository's templates
+// instead of appending to them.
+func (r *Repository) RegisterRoleTemplate(t *RoleTemplate) error {
+	if t == nil {
+		return fmt.Errorf("register role template: missing template: %w", errors.ErrInvalidParameter)
+	}
+	if err := t.validate(); err != nil {
+		return fmt.Errorf("register role template: %w", err)
+	}
+	r.roleTemplates = append(r.roleTemplates, t)
+	return nil
+}
+
+// isSpecialUser reports whether userId is one that CreateScope should never
+// assign a role to directly: the anonymous/auth/recovery sentinel users, or
+// an unset id (the administrative recovery workflow calls CreateScope this
+// way).
+func isSpecialUser(userId string) bool {
+	switch userId {
+	case "", "u_anon", "u_auth", "u_recovery":
+		return true
+	}
+	return false
+}
+
+// roleTemplatesFor returns the role templates that should be provisioned
+// for a scope of s's type: opts.withRoleTemplates if the caller supplied an
+// override for this CreateScope call, else r.roleTemplates, else the
+// built-in defaults. Templates that don't apply to s's type, that are
+// disabled via the legacy WithSkipAdminRoleCreation/
+// WithSkipDefaultRoleCreation options, or that would assign a role to a
+// special user, are filtered out.
+func (r *Repository) roleTemplatesFor(s *Scope, userId string, opts options) []*RoleTemplate {
+	templates := opts.withRoleTemplates
+	if len(templates) == 0 {
+		templates = r.roleTemplates
+	}
+	if len(templates) == 0 {
+		templates = defaultRoleTemplates()
+	}
+
+	scopeType := scope.Map[s.Type]
+	out := make([]*RoleTemplate, 0, len(templates))
+	for _, t := range templates {
+		if !t.appliesTo(scopeType) {
+			continue
+		}
+		if t.isBuiltinAdmin && opts.withSkipAdminRoleCreation {
+			continue
+		}
+		if t.isBuiltinDefault && opts.withSkipDefaultRoleCreation {
+			continue
+		}
+		if t.AssignCreatingUser && isSpecialUser(userId) {
+			// TODO: Cause a log entry. The repo doesn't have a logger right
+			// now, and ideally we will be using context to pass around log
+			// info scoped to this request for grouped display in the
+			// server log. The only reason this should ever happen anyways
+			// is via the administrative recovery workflow so it's already
+			// a special case.
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// WithRoleTemplates overrides the role templates used for a single
+// CreateScope call, rather than those registered on the Repository via
+// RegisterRoleTemplate (or the built-in defaults if none are registered).
+func WithRoleTemplates(templates ...*RoleTemplate) Option {
+	return func(o *options) {
+		o.withRoleTemplates = templates
+	}
+}
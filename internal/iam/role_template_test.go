@@ -0,0 +1,147 @@
+package iam
+
+import (
+	"testing"
+
+	"github.com/hashicorp/boundary/internal/types/scope"
+)
+
+// TestRoleTemplate_appliesTo verifies the scope-type filtering a template
+// opts into via ScopeTypes (or every type, if unset).
+func TestRoleTemplate_appliesTo(t *testing.T) {
+	tests := []struct {
+		name  string
+		tmpl  *RoleTemplate
+		st    scope.Type
+		wants bool
+	}{
+		{
+			name:  "empty ScopeTypes applies to org",
+			tmpl:  &RoleTemplate{},
+			st:    scope.Org,
+			wants: true,
+		},
+		{
+			name:  "empty ScopeTypes applies to project",
+			tmpl:  &RoleTemplate{},
+			st:    scope.Project,
+			wants: true,
+		},
+		{
+			name:  "restricted to org does not apply to project",
+			tmpl:  &RoleTemplate{ScopeTypes: []scope.Type{scope.Org}},
+			st:    scope.Project,
+			wants: false,
+		},
+		{
+			name:  "restricted to org applies to org",
+			tmpl:  &RoleTemplate{ScopeTypes: []scope.Type{scope.Org}},
+			st:    scope.Org,
+			wants: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tmpl.appliesTo(tt.st); got != tt.wants {
+				t.Errorf("appliesTo(%s) = %v, want %v", tt.st, got, tt.wants)
+			}
+		})
+	}
+}
+
+func TestRoleTemplate_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    *RoleTemplate
+		wantErr bool
+	}{
+		{
+			name:    "missing name",
+			tmpl:    &RoleTemplate{Grants: []string{"id=*;type=*;actions=*"}},
+			wantErr: true,
+		},
+		{
+			name:    "missing grants",
+			tmpl:    &RoleTemplate{Name: "Custom"},
+			wantErr: true,
+		},
+		{
+			name:    "valid",
+			tmpl:    &RoleTemplate{Name: "Custom", Grants: []string{"id=*;type=*;actions=*"}},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tmpl.validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestRepository_roleTemplatesFor_identityNotName verifies that
+// WithSkipAdminRoleCreation/WithSkipDefaultRoleCreation filter the built-in
+// templates by their isBuiltinAdmin/isBuiltinDefault markers, not by
+// matching their Name -- an operator-registered template that happens to
+// reuse one of those names must survive the skip.
+func TestRepository_roleTemplatesFor_identityNotName(t *testing.T) {
+	customAdmin := &RoleTemplate{
+		Name:   "Administration",
+		Grants: []string{"id=*;type=target;actions=read"},
+	}
+	r := &Repository{
+		roleTemplates: append(defaultRoleTemplates(), customAdmin),
+	}
+
+	org, err := NewScope(scope.Org)
+	if err != nil {
+		t.Fatalf("NewScope(scope.Org) error = %v", err)
+	}
+
+	opts := getOpts(WithSkipAdminRoleCreation(), WithSkipDefaultRoleCreation())
+	got := r.roleTemplatesFor(org, "u_1234567890", opts)
+
+	var sawBuiltinAdmin, sawCustomAdmin, sawDefault bool
+	for _, tmpl := range got {
+		switch {
+		case tmpl.isBuiltinAdmin:
+			sawBuiltinAdmin = true
+		case tmpl.isBuiltinDefault:
+			sawDefault = true
+		case tmpl.Name == "Administration":
+			sawCustomAdmin = true
+		}
+	}
+	if sawBuiltinAdmin {
+		t.Error("roleTemplatesFor: built-in Administration template should have been skipped")
+	}
+	if sawDefault {
+		t.Error("roleTemplatesFor: built-in Login and Default Grants template should have been skipped")
+	}
+	if !sawCustomAdmin {
+		t.Error("roleTemplatesFor: custom template named \"Administration\" should not have been skipped")
+	}
+}
+
+// TestRepository_roleTemplatesFor_specialUser verifies that a template
+// assigning the creating user is skipped when that user is a sentinel
+// (anonymous/auth/recovery) or unset.
+func TestRepository_roleTemplatesFor_specialUser(t *testing.T) {
+	r := &Repository{}
+	org, err := NewScope(scope.Org)
+	if err != nil {
+		t.Fatalf("NewScope(scope.Org) error = %v", err)
+	}
+	opts := getOpts()
+
+	for _, userId := range []string{"", "u_anon", "u_auth", "u_recovery"} {
+		got := r.roleTemplatesFor(org, userId, opts)
+		for _, tmpl := range got {
+			if tmpl.isBuiltinAdmin {
+				t.Errorf("roleTemplatesFor(userId=%q): Administration template (AssignCreatingUser) should have been skipped for a special user", userId)
+			}
+		}
+	}
+}
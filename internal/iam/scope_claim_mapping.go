@@ -0,0 +1,243 @@
+package iam
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/boundary/internal/errors"
+	"github.com/hashicorp/boundary/internal/types/scope"
+)
+
+// ScopeClaimRule maps values found at Claim (a top-level key into the
+// claims map passed to SyncScopesFromClaims, e.g. "groups",
+// "organizations", or a custom "boundary_scopes") to a scope that should
+// exist under ParentScopeId. Pattern is matched against each claim value;
+// on a match, ScopeNameTemplate is expanded against Pattern's submatches
+// (using regexp.Regexp.ExpandString syntax, e.g. "team-$1") to produce the
+// scope's Name.
+type ScopeClaimRule struct {
+	Claim             string
+	Pattern           *regexp.Regexp
+	ScopeNameTemplate string
+	ParentScopeId     string
+	ScopeType         string
+}
+
+func (r *ScopeClaimRule) validate() error {
+	if r.Claim == "" {
+		return fmt.Errorf("scope claim rule: missing claim: %w", errors.ErrInvalidParameter)
+	}
+	if r.Pattern == nil {
+		return fmt.Errorf("scope claim rule: missing pattern: %w", errors.ErrInvalidParameter)
+	}
+	if r.ScopeNameTemplate == "" {
+		return fmt.Errorf("scope claim rule: missing scope name template: %w", errors.ErrInvalidParameter)
+	}
+	if r.ParentScopeId == "" {
+		return fmt.Errorf("scope claim rule: missing parent scope id: %w", errors.ErrInvalidParameter)
+	}
+	switch r.ScopeType {
+	case scope.Org.String(), scope.Project.String():
+	default:
+		return fmt.Errorf("scope claim rule: scope type must be %q or %q: %w", scope.Org.String(), scope.Project.String(), errors.ErrInvalidParameter)
+	}
+	return nil
+}
+
+// scopeName expands ScopeNameTemplate against the submatches Pattern found
+// in value. It assumes Pattern already matched value.
+func (r *ScopeClaimRule) scopeName(value string) string {
+	match := r.Pattern.FindStringSubmatchIndex(value)
+	return string(r.Pattern.ExpandString(nil, r.ScopeNameTemplate, value, match))
+}
+
+// ScopeClaimMapping configures SyncScopesFromClaims and
+// PruneUnclaimedScopes: which claims to read and how to turn their values
+// into org/project scopes.
+type ScopeClaimMapping struct {
+	Rules []ScopeClaimRule
+}
+
+func (m ScopeClaimMapping) validate() error {
+	if len(m.Rules) == 0 {
+		return fmt.Errorf("scope claim mapping: no rules configured: %w", errors.ErrInvalidParameter)
+	}
+	for i := range m.Rules {
+		if err := m.Rules[i].validate(); err != nil {
+			return fmt.Errorf("scope claim mapping: rule %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// claimStrings normalizes a claim value -- which per the JWT/OIDC claims
+// convention may be a single string or a list of strings -- into a string
+// slice.
+func claimStrings(v interface{}) []string {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	case []interface{}:
+		out := make([]string, 0, len(t))
+		for _, e := range t {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// claimSyncMarker is recorded in the Description of every scope
+// SyncScopesFromClaims creates, so PruneUnclaimedScopes can tell scopes it
+// provisioned apart from manually-created scopes that simply happen to
+// share a parent and name. A scope without this marker is never deleted by
+// PruneUnclaimedScopes, no matter what its name is.
+const claimSyncMarker = "managed-by-claim-sync"
+
+// isClaimSyncManaged reports whether s was created by SyncScopesFromClaims.
+func isClaimSyncManaged(s *Scope) bool {
+	return s.Description == claimSyncMarker
+}
+
+// SyncScopesFromClaims reconciles orgs/projects against the claims of an
+// authenticated user's token: for every ScopeClaimRule in mapping whose
+// Pattern matches a value of the rule's Claim, a scope named per the
+// rule's ScopeNameTemplate is created under ParentScopeId if it doesn't
+// already exist, with userId assigned to its auto-generated Administration
+// role. Scopes it creates are tagged with claimSyncMarker so
+// PruneUnclaimedScopes can later recognize them.
+//
+// SyncScopesFromClaims only ever adds scopes, never removes them: a single
+// login's claims are one user's view of group/org membership, not the
+// full set of scopes every other user's claims have provisioned, so
+// deleting based on them here would delete scopes other users still need.
+// Use PruneUnclaimedScopes, fed the claims of every known principal, to
+// safely reconcile deletions.
+//
+// It returns every scope the claims currently resolve to (whether newly
+// created or already present).
+func (r *Repository) SyncScopesFromClaims(ctx context.Context, userId string, claims map[string]interface{}, mapping ScopeClaimMapping) ([]*Scope, error) {
+	if userId == "" {
+		return nil, fmt.Errorf("sync scopes from claims: missing user id: %w", errors.ErrInvalidParameter)
+	}
+	if err := mapping.validate(); err != nil {
+		return nil, fmt.Errorf("sync scopes from claims: %w", err)
+	}
+
+	var synced []*Scope
+	for _, rule := range mapping.Rules {
+		for _, v := range claimStrings(claims[rule.Claim]) {
+			if !rule.Pattern.MatchString(v) {
+				continue
+			}
+			name := rule.scopeName(v)
+
+			existing, err := r.lookupScopeByName(ctx, rule.ParentScopeId, name)
+			if err != nil {
+				return nil, fmt.Errorf("sync scopes from claims: %w", err)
+			}
+			if existing != nil {
+				synced = append(synced, existing)
+				continue
+			}
+
+			s, err := NewScope(scope.Map[rule.ScopeType], WithName(name), WithDescription(claimSyncMarker))
+			if err != nil {
+				return nil, fmt.Errorf("sync scopes from claims: error instantiating scope %q: %w", name, err)
+			}
+			s.ParentId = rule.ParentScopeId
+			created, err := r.CreateScope(ctx, s, userId)
+			if err != nil {
+				return nil, fmt.Errorf("sync scopes from claims: error creating scope %q: %w", name, err)
+			}
+			synced = append(synced, created)
+		}
+	}
+
+	return synced, nil
+}
+
+// PruneUnclaimedScopes deletes claim-sync-managed scopes (see
+// claimSyncMarker) under each of mapping's rules' ParentScopeId whose name
+// no longer matches any claim value in allClaims.
+//
+// allClaims must be the claims of every principal SyncScopesFromClaims is
+// run for, not just one user's -- unlike a single login's token, this is
+// the authoritative set PruneUnclaimedScopes diffs existing scopes
+// against, so callers should run this as a periodic reconciliation pass
+// (e.g. against a directory/IdP group listing) rather than from a single
+// user's login. Scopes without claimSyncMarker, including any
+// manually-created scope that happens to share a parent and name with a
+// rule, are never touched.
+//
+// It returns every scope that was deleted.
+func (r *Repository) PruneUnclaimedScopes(ctx context.Context, mapping ScopeClaimMapping, allClaims []map[string]interface{}) ([]*Scope, error) {
+	if err := mapping.validate(); err != nil {
+		return nil, fmt.Errorf("prune unclaimed scopes: %w", err)
+	}
+
+	wantedByParent := make(map[string]map[string]bool)
+	for _, rule := range mapping.Rules {
+		if wantedByParent[rule.ParentScopeId] == nil {
+			wantedByParent[rule.ParentScopeId] = make(map[string]bool)
+		}
+		for _, claims := range allClaims {
+			for _, v := range claimStrings(claims[rule.Claim]) {
+				if rule.Pattern.MatchString(v) {
+					wantedByParent[rule.ParentScopeId][rule.scopeName(v)] = true
+				}
+			}
+		}
+	}
+
+	var pruned []*Scope
+	for parentId, wanted := range wantedByParent {
+		existing, err := r.childScopes(ctx, parentId)
+		if err != nil {
+			return nil, fmt.Errorf("prune unclaimed scopes: unable to list scopes under %s: %w", parentId, err)
+		}
+		for _, c := range existing {
+			if !isClaimSyncManaged(c) || wanted[c.Name] {
+				continue
+			}
+			if _, err := r.DeleteScope(ctx, c.PublicId); err != nil {
+				return nil, fmt.Errorf("prune unclaimed scopes: unable to prune scope %s: %w", c.PublicId, err)
+			}
+			pruned = append(pruned, c)
+		}
+	}
+
+	return pruned, nil
+}
+
+// lookupScopeByName looks up a scope by its (parentId, name) pair, the
+// natural key claim-driven sync reconciles against, rather than by public
+// id. Returns nil, nil if no such scope exists.
+func (r *Repository) lookupScopeByName(ctx context.Context, parentId, name string) (*Scope, error) {
+	var scopes []*Scope
+	if err := r.list(ctx, &scopes, "parent_id = ? and name = ?", []interface{}{parentId, name}); err != nil {
+		return nil, fmt.Errorf("lookup scope by name: %w", err)
+	}
+	if len(scopes) == 0 {
+		return nil, nil
+	}
+	return scopes[0], nil
+}
+
+// childScopes returns every org or project scope directly parented by
+// parentId, for PruneUnclaimedScopes's reconciliation pass.
+func (r *Repository) childScopes(ctx context.Context, parentId string) ([]*Scope, error) {
+	var scopes []*Scope
+	if err := r.list(ctx, &scopes, "parent_id = ?", []interface{}{parentId}); err != nil {
+		return nil, fmt.Errorf("child scopes: %w", err)
+	}
+	return scopes, nil
+}
@@ -0,0 +1,159 @@
+package iam
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/boundary/internal/types/scope"
+)
+
+func TestClaimStrings(t *testing.T) {
+	tests := []struct {
+		name string
+		in   interface{}
+		want []string
+	}{
+		{name: "nil", in: nil, want: nil},
+		{name: "single string", in: "team-a", want: []string{"team-a"}},
+		{name: "string slice", in: []string{"team-a", "team-b"}, want: []string{"team-a", "team-b"}},
+		{
+			name: "interface slice of strings",
+			in:   []interface{}{"team-a", "team-b"},
+			want: []string{"team-a", "team-b"},
+		},
+		{
+			name: "interface slice drops non-strings",
+			in:   []interface{}{"team-a", 42, "team-b"},
+			want: []string{"team-a", "team-b"},
+		},
+		{name: "unsupported type", in: 42, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := claimStrings(tt.in)
+			if len(got) != len(tt.want) {
+				t.Fatalf("claimStrings(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("claimStrings(%v) = %v, want %v", tt.in, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestScopeClaimRule_validate(t *testing.T) {
+	validRule := func() ScopeClaimRule {
+		return ScopeClaimRule{
+			Claim:             "groups",
+			Pattern:           regexp.MustCompile(`^team-(.+)$`),
+			ScopeNameTemplate: "team-$1",
+			ParentScopeId:     "o_1234567890",
+			ScopeType:         scope.Project.String(),
+		}
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		r := validRule()
+		if err := r.validate(); err != nil {
+			t.Fatalf("validate() error = %v", err)
+		}
+	})
+	t.Run("missing claim", func(t *testing.T) {
+		r := validRule()
+		r.Claim = ""
+		if err := r.validate(); err == nil {
+			t.Fatal("validate() should have errored on missing claim")
+		}
+	})
+	t.Run("missing pattern", func(t *testing.T) {
+		r := validRule()
+		r.Pattern = nil
+		if err := r.validate(); err == nil {
+			t.Fatal("validate() should have errored on missing pattern")
+		}
+	})
+	t.Run("missing scope name template", func(t *testing.T) {
+		r := validRule()
+		r.ScopeNameTemplate = ""
+		if err := r.validate(); err == nil {
+			t.Fatal("validate() should have errored on missing scope name template")
+		}
+	})
+	t.Run("missing parent scope id", func(t *testing.T) {
+		r := validRule()
+		r.ParentScopeId = ""
+		if err := r.validate(); err == nil {
+			t.Fatal("validate() should have errored on missing parent scope id")
+		}
+	})
+	t.Run("invalid scope type", func(t *testing.T) {
+		r := validRule()
+		r.ScopeType = "global"
+		if err := r.validate(); err == nil {
+			t.Fatal("validate() should have errored on a scope type that isn't org or project")
+		}
+	})
+}
+
+func TestScopeClaimRule_scopeName(t *testing.T) {
+	r := ScopeClaimRule{
+		Pattern:           regexp.MustCompile(`^team-(.+)$`),
+		ScopeNameTemplate: "team-$1",
+	}
+	if got, want := r.scopeName("team-payments"), "team-payments"; got != want {
+		t.Errorf("scopeName() = %q, want %q", got, want)
+	}
+}
+
+func TestScopeClaimMapping_validate(t *testing.T) {
+	rule := ScopeClaimRule{
+		Claim:             "groups",
+		Pattern:           regexp.MustCompile(`^team-(.+)$`),
+		ScopeNameTemplate: "team-$1",
+		ParentScopeId:     "o_1234567890",
+		ScopeType:         scope.Project.String(),
+	}
+
+	t.Run("no rules", func(t *testing.T) {
+		m := ScopeClaimMapping{}
+		if err := m.validate(); err == nil {
+			t.Fatal("validate() should have errored with no rules configured")
+		}
+	})
+	t.Run("invalid rule", func(t *testing.T) {
+		bad := rule
+		bad.Claim = ""
+		m := ScopeClaimMapping{Rules: []ScopeClaimRule{bad}}
+		if err := m.validate(); err == nil {
+			t.Fatal("validate() should surface an invalid rule's error")
+		}
+	})
+	t.Run("valid", func(t *testing.T) {
+		m := ScopeClaimMapping{Rules: []ScopeClaimRule{rule}}
+		if err := m.validate(); err != nil {
+			t.Fatalf("validate() error = %v", err)
+		}
+	})
+}
+
+// TestIsClaimSyncManaged verifies the provenance marker PruneUnclaimedScopes
+// relies on to avoid ever deleting a manually-created scope that merely
+// happens to share a parent and name with a claim-sync rule.
+func TestIsClaimSyncManaged(t *testing.T) {
+	managed := &Scope{Description: claimSyncMarker}
+	if !isClaimSyncManaged(managed) {
+		t.Error("isClaimSyncManaged should be true for a scope created by SyncScopesFromClaims")
+	}
+
+	manual := &Scope{Description: "created by an operator"}
+	if isClaimSyncManaged(manual) {
+		t.Error("isClaimSyncManaged should be false for a manually-created scope, even with a matching name/parent")
+	}
+
+	blank := &Scope{}
+	if isClaimSyncManaged(blank) {
+		t.Error("isClaimSyncManaged should be false for a scope with no description")
+	}
+}
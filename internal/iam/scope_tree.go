@@ -0,0 +1,574 @@
+package iam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/boundary/internal/db"
+	"github.com/hashicorp/boundary/internal/errors"
+	"github.com/hashicorp/boundary/internal/kms"
+	"github.com/hashicorp/boundary/internal/oplog"
+	"github.com/hashicorp/boundary/internal/types/resource"
+	"github.com/hashicorp/boundary/internal/types/scope"
+)
+
+// scopeTreeQuery is a recursive CTE that returns rootId and every scope
+// transitively parented by it (the full org→project hierarchy) in one
+// round trip, rather than the level-at-a-time pattern ListOrgs/ListProjects
+// use.
+const scopeTreeQuery = `
+with recursive scope_tree (public_id, parent_id, name, description, type, version) as (
+	select public_id, parent_id, name, description, type, version
+	from iam_scope
+	where public_id = ?
+	union all
+	select s.public_id, s.parent_id, s.name, s.description, s.type, s.version
+	from iam_scope s
+	join scope_tree st on s.parent_id = st.public_id
+)
+select public_id, coalesce(parent_id, ''), coalesce(name, ''), coalesce(description, ''), type, version from scope_tree
+`
+
+// ScopeNode is one scope in a hierarchy returned by ListScopeTree, with its
+// direct children attached.
+type ScopeNode struct {
+	Scope    *Scope
+	Children []*ScopeNode
+}
+
+// ListScopeTree returns the full hierarchy rooted at rootId -- an org and
+// every project under it, or (for rootId == global) every org and
+// project -- as a single in-memory tree, built from one recursive-CTE
+// query against iam_scope rather than one ListProjects call per org.
+func (r *Repository) ListScopeTree(ctx context.Context, rootId string, opt ...Option) (*ScopeNode, error) {
+	if rootId == "" {
+		return nil, fmt.Errorf("list scope tree: missing root id: %w", errors.ErrInvalidParameter)
+	}
+
+	rows, err := r.reader.Query(ctx, scopeTreeQuery, []interface{}{rootId})
+	if err != nil {
+		return nil, fmt.Errorf("list scope tree: %w", err)
+	}
+	defer rows.Close()
+
+	nodesById := make(map[string]*ScopeNode)
+	for rows.Next() {
+		s := allocScope()
+		if err := rows.Scan(&s.PublicId, &s.ParentId, &s.Name, &s.Description, &s.Type, &s.Version); err != nil {
+			return nil, fmt.Errorf("list scope tree: %w", err)
+		}
+		nodesById[s.PublicId] = &ScopeNode{Scope: &s}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list scope tree: %w", err)
+	}
+
+	root, ok := nodesById[rootId]
+	if !ok {
+		return nil, fmt.Errorf("list scope tree: %s: %w", rootId, errors.ErrRecordNotFound)
+	}
+	for publicId, node := range nodesById {
+		if publicId == rootId {
+			continue
+		}
+		parent, ok := nodesById[node.Scope.ParentId]
+		if !ok {
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+	return root, nil
+}
+
+// exportedPrincipal is a role principal in a ScopeTreeExport. A principal's
+// own public id is cluster-local, just like a role's (see exportedRole),
+// so it isn't recorded; instead exportedPrincipal carries a reference
+// ImportScopeTree can re-resolve against the destination cluster:
+//   - a user is referenced by the login name of its primary auth account
+//     together with that account's auth method's Name -- both
+//     operator-assigned, and expected to be reproduced on the destination,
+//     unlike the auto-generated user/account/auth-method ids.
+//   - a group is referenced by its own Name together with the name path
+//     of the scope it lives in, walked from global down (e.g.
+//     ["Acme Corp", "payments"]), since groups have no login name
+//     equivalent to anchor on.
+//
+// Importing a role whose principal can't be re-resolved this way fails
+// the import outright rather than silently dropping the principal or
+// wiring the grant to whatever happens to hold that id on the destination.
+type exportedPrincipal struct {
+	// Type is "user" or "group", recorded since a role's principals are
+	// split across the iam_user_role/iam_group_role tables and need to be
+	// recreated against the right one.
+	Type string `json:"type"`
+
+	// Set when Type == "user".
+	AuthMethodName string `json:"auth_method_name,omitempty"`
+	LoginName      string `json:"login_name,omitempty"`
+
+	// Set when Type == "group".
+	GroupName      string   `json:"group_name,omitempty"`
+	GroupScopePath []string `json:"group_scope_path,omitempty"`
+}
+
+// exportedRole is a role in a ScopeTreeExport, along with its grants and
+// principals.
+type exportedRole struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Grants      []string            `json:"grants"`
+	Principals  []exportedPrincipal `json:"principals"`
+}
+
+// exportedScope is one scope in a ScopeTreeExport: its portable attributes
+// (not its public id, which is cluster-specific), its roles, and its
+// children.
+type exportedScope struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Type        string           `json:"type"`
+	Roles       []exportedRole   `json:"roles"`
+	Children    []*exportedScope `json:"children"`
+}
+
+// ScopeTreeExport is the stable, versioned JSON document ExportScopeTree
+// produces and ImportScopeTree consumes. SchemaVersion guards against a
+// future, incompatible export format being fed to an older ImportScopeTree.
+type ScopeTreeExport struct {
+	SchemaVersion int            `json:"schema_version"`
+	Root          *exportedScope `json:"root"`
+}
+
+const scopeTreeExportSchemaVersion = 1
+
+// ExportScopeTree walks the hierarchy rooted at rootId and serializes it,
+// along with every role, grant, and principal assignment in it, to a
+// stable JSON document. Pair with ImportScopeTree to clone a "template
+// tenant" or migrate scopes between Boundary clusters.
+func (r *Repository) ExportScopeTree(ctx context.Context, rootId string, opt ...Option) ([]byte, error) {
+	tree, err := r.ListScopeTree(ctx, rootId, opt...)
+	if err != nil {
+		return nil, fmt.Errorf("export scope tree: %w", err)
+	}
+	root, err := r.exportNode(ctx, tree)
+	if err != nil {
+		return nil, fmt.Errorf("export scope tree: %w", err)
+	}
+	doc := &ScopeTreeExport{
+		SchemaVersion: scopeTreeExportSchemaVersion,
+		Root:          root,
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("export scope tree: %w", err)
+	}
+	return data, nil
+}
+
+func (r *Repository) exportNode(ctx context.Context, node *ScopeNode) (*exportedScope, error) {
+	roles, err := r.exportRoles(ctx, node.Scope.PublicId)
+	if err != nil {
+		return nil, err
+	}
+	out := &exportedScope{
+		Name:        node.Scope.Name,
+		Description: node.Scope.Description,
+		Type:        node.Scope.Type,
+		Roles:       roles,
+		Children:    make([]*exportedScope, 0, len(node.Children)),
+	}
+	for _, child := range node.Children {
+		exportedChild, err := r.exportNode(ctx, child)
+		if err != nil {
+			return nil, err
+		}
+		out.Children = append(out.Children, exportedChild)
+	}
+	return out, nil
+}
+
+func (r *Repository) exportRoles(ctx context.Context, scopeId string) ([]exportedRole, error) {
+	var roles []*Role
+	if err := r.reader.SearchWhere(ctx, &roles, "scope_id = ?", []interface{}{scopeId}); err != nil {
+		return nil, fmt.Errorf("unable to list roles for %s: %w", scopeId, err)
+	}
+
+	out := make([]exportedRole, 0, len(roles))
+	for _, role := range roles {
+		var grants []*RoleGrant
+		if err := r.reader.SearchWhere(ctx, &grants, "role_id = ?", []interface{}{role.PublicId}); err != nil {
+			return nil, fmt.Errorf("unable to list grants for role %s: %w", role.PublicId, err)
+		}
+		grantStrings := make([]string, 0, len(grants))
+		for _, g := range grants {
+			grantStrings = append(grantStrings, g.CanonicalGrant)
+		}
+
+		var userRoles []*UserRole
+		if err := r.reader.SearchWhere(ctx, &userRoles, "role_id = ?", []interface{}{role.PublicId}); err != nil {
+			return nil, fmt.Errorf("unable to list user principals for role %s: %w", role.PublicId, err)
+		}
+		var groupRoles []*GroupRole
+		if err := r.reader.SearchWhere(ctx, &groupRoles, "role_id = ?", []interface{}{role.PublicId}); err != nil {
+			return nil, fmt.Errorf("unable to list group principals for role %s: %w", role.PublicId, err)
+		}
+		principals := make([]exportedPrincipal, 0, len(userRoles)+len(groupRoles))
+		for _, ur := range userRoles {
+			authMethodName, loginName, err := r.userPrincipalRef(ctx, ur.PrincipalId)
+			if err != nil {
+				return nil, fmt.Errorf("unable to export user principal %s for role %s: %w", ur.PrincipalId, role.PublicId, err)
+			}
+			principals = append(principals, exportedPrincipal{Type: "user", AuthMethodName: authMethodName, LoginName: loginName})
+		}
+		for _, gr := range groupRoles {
+			name, scopePath, err := r.groupPrincipalRef(ctx, gr.PrincipalId)
+			if err != nil {
+				return nil, fmt.Errorf("unable to export group principal %s for role %s: %w", gr.PrincipalId, role.PublicId, err)
+			}
+			principals = append(principals, exportedPrincipal{Type: "group", GroupName: name, GroupScopePath: scopePath})
+		}
+
+		out = append(out, exportedRole{
+			Name:        role.Name,
+			Description: role.Description,
+			Grants:      grantStrings,
+			Principals:  principals,
+		})
+	}
+	return out, nil
+}
+
+// userPrimaryAccountRefQuery resolves a user to the login name of its
+// oldest auth account and that account's auth method's Name -- the
+// portable reference exportedPrincipal records in place of the user's own
+// cluster-local public id.
+const userPrimaryAccountRefQuery = `
+select am.name, aa.login_name
+from auth_account aa
+join auth_method am on am.public_id = aa.auth_method_id
+where aa.iam_user_id = ?
+order by aa.create_time asc
+limit 1
+`
+
+// userPrincipalRef resolves userId to the (auth method name, login name)
+// pair ImportScopeTree will re-resolve on the destination cluster.
+func (r *Repository) userPrincipalRef(ctx context.Context, userId string) (authMethodName, loginName string, err error) {
+	rows, err := r.reader.Query(ctx, userPrimaryAccountRefQuery, []interface{}{userId})
+	if err != nil {
+		return "", "", fmt.Errorf("unable to look up auth account for user %s: %w", userId, err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return "", "", fmt.Errorf("user %s has no auth account to derive a portable reference from: %w", userId, errors.ErrRecordNotFound)
+	}
+	if err := rows.Scan(&authMethodName, &loginName); err != nil {
+		return "", "", fmt.Errorf("unable to look up auth account for user %s: %w", userId, err)
+	}
+	return authMethodName, loginName, nil
+}
+
+// groupRefQuery resolves a group to its own Name and the id of the scope
+// it lives in, so groupPrincipalRef can turn that scope id into a
+// portable name path.
+const groupRefQuery = `select scope_id, name from iam_group where public_id = ?`
+
+// groupPrincipalRef resolves groupId to its Name and the name path (from
+// global down) of the scope it lives in -- the portable reference
+// ImportScopeTree will re-resolve on the destination cluster.
+func (r *Repository) groupPrincipalRef(ctx context.Context, groupId string) (name string, scopePath []string, err error) {
+	rows, err := r.reader.Query(ctx, groupRefQuery, []interface{}{groupId})
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to look up group %s: %w", groupId, err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return "", nil, fmt.Errorf("group %s not found: %w", groupId, errors.ErrRecordNotFound)
+	}
+	var groupScopeId string
+	if err := rows.Scan(&groupScopeId, &name); err != nil {
+		return "", nil, fmt.Errorf("unable to look up group %s: %w", groupId, err)
+	}
+	rows.Close()
+
+	scopePath, err = r.scopeNamePath(ctx, groupScopeId)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to resolve scope path for group %s: %w", groupId, err)
+	}
+	return name, scopePath, nil
+}
+
+// scopeNamePath returns the Name of every scope from (but not including)
+// global down to and including scopeId, in root-to-leaf order. An empty
+// slice means scopeId is global itself.
+func (r *Repository) scopeNamePath(ctx context.Context, scopeId string) ([]string, error) {
+	var path []string
+	for scopeId != "" && scopeId != scope.Global.String() {
+		s, err := r.LookupScope(ctx, scopeId)
+		if err != nil {
+			return nil, fmt.Errorf("unable to look up scope %s: %w", scopeId, err)
+		}
+		if s == nil {
+			return nil, fmt.Errorf("scope %s: %w", scopeId, errors.ErrRecordNotFound)
+		}
+		path = append([]string{s.Name}, path...)
+		scopeId = s.ParentId
+	}
+	return path, nil
+}
+
+// userIdByAccountRefQuery is the reverse of userPrimaryAccountRefQuery:
+// given the portable reference exportedPrincipal recorded for a user,
+// find that user's public id on this (the destination) cluster.
+const userIdByAccountRefQuery = `
+select aa.iam_user_id
+from auth_account aa
+join auth_method am on am.public_id = aa.auth_method_id
+where am.name = ? and aa.login_name = ?
+`
+
+// lookupUserByPrincipalRef resolves the (auth method name, login name)
+// pair an exportedPrincipal recorded back to a user's public id on this
+// cluster. Returns ErrRecordNotFound if no such account exists here.
+func (r *Repository) lookupUserByPrincipalRef(ctx context.Context, authMethodName, loginName string) (string, error) {
+	rows, err := r.reader.Query(ctx, userIdByAccountRefQuery, []interface{}{authMethodName, loginName})
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve user %s/%s: %w", authMethodName, loginName, err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return "", fmt.Errorf("no user found for auth method %q login name %q: %w", authMethodName, loginName, errors.ErrRecordNotFound)
+	}
+	var userId string
+	if err := rows.Scan(&userId); err != nil {
+		return "", fmt.Errorf("unable to resolve user %s/%s: %w", authMethodName, loginName, err)
+	}
+	return userId, nil
+}
+
+// lookupGroupByPrincipalRef resolves the (name, scope path) pair an
+// exportedPrincipal recorded back to a group's public id on this cluster.
+// Returns ErrRecordNotFound if no such scope or group exists here.
+func (r *Repository) lookupGroupByPrincipalRef(ctx context.Context, name string, scopePath []string) (string, error) {
+	scopeId, err := r.lookupScopeByNamePath(ctx, scopePath)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve scope for group %q: %w", name, err)
+	}
+	rows, err := r.reader.Query(ctx, "select public_id from iam_group where scope_id = ? and name = ?", []interface{}{scopeId, name})
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve group %q: %w", name, err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return "", fmt.Errorf("no group named %q under scope %s: %w", name, scopeId, errors.ErrRecordNotFound)
+	}
+	var groupId string
+	if err := rows.Scan(&groupId); err != nil {
+		return "", fmt.Errorf("unable to resolve group %q: %w", name, err)
+	}
+	return groupId, nil
+}
+
+// lookupScopeByNamePath resolves a name path (as produced by
+// scopeNamePath) against this cluster's scope hierarchy, walking down
+// from global, and returns the id of the scope at the end of the path. An
+// empty path resolves to global itself.
+func (r *Repository) lookupScopeByNamePath(ctx context.Context, path []string) (string, error) {
+	parentId := scope.Global.String()
+	for _, name := range path {
+		s, err := r.lookupScopeByName(ctx, parentId, name)
+		if err != nil {
+			return "", err
+		}
+		if s == nil {
+			return "", fmt.Errorf("no scope named %q under %s: %w", name, parentId, errors.ErrRecordNotFound)
+		}
+		parentId = s.PublicId
+	}
+	return parentId, nil
+}
+
+// ImportScopeTree re-materializes a document produced by ExportScopeTree
+// under newParentId (an org, if the export's root is a project, or global
+// if the export's root is an org), returning the newly created hierarchy.
+// userId is recorded as the creating user for every new scope; since the
+// export already carries its own role/grant/principal definitions,
+// ImportScopeTree suppresses the usual templated default roles via
+// WithSkipAdminRoleCreation/WithSkipDefaultRoleCreation and recreates
+// exactly the roles the export describes instead.
+func (r *Repository) ImportScopeTree(ctx context.Context, data []byte, newParentId, userId string, opt ...Option) (*ScopeNode, error) {
+	if newParentId == "" {
+		return nil, fmt.Errorf("import scope tree: missing new parent id: %w", errors.ErrInvalidParameter)
+	}
+	if userId == "" {
+		return nil, fmt.Errorf("import scope tree: missing user id: %w", errors.ErrInvalidParameter)
+	}
+
+	var doc ScopeTreeExport
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("import scope tree: %w", err)
+	}
+	if doc.SchemaVersion != scopeTreeExportSchemaVersion {
+		return nil, fmt.Errorf("import scope tree: unsupported schema version %d (expected %d): %w", doc.SchemaVersion, scopeTreeExportSchemaVersion, errors.ErrInvalidParameter)
+	}
+	if doc.Root == nil {
+		return nil, fmt.Errorf("import scope tree: export has no root scope: %w", errors.ErrInvalidParameter)
+	}
+
+	return r.importNode(ctx, doc.Root, newParentId, userId)
+}
+
+func (r *Repository) importNode(ctx context.Context, exported *exportedScope, parentId, userId string) (*ScopeNode, error) {
+	s, err := NewScope(scope.Map[exported.Type], WithName(exported.Name), WithDescription(exported.Description))
+	if err != nil {
+		return nil, fmt.Errorf("unable to instantiate scope %q: %w", exported.Name, err)
+	}
+	s.ParentId = parentId
+	created, err := r.CreateScope(ctx, s, userId, WithSkipAdminRoleCreation(), WithSkipDefaultRoleCreation())
+	if err != nil {
+		return nil, fmt.Errorf("unable to create scope %q: %w", exported.Name, err)
+	}
+
+	for _, role := range exported.Roles {
+		if _, err := r.createImportedRole(ctx, created.PublicId, role); err != nil {
+			return nil, fmt.Errorf("unable to recreate role %q under %s: %w", role.Name, created.PublicId, err)
+		}
+	}
+
+	node := &ScopeNode{Scope: created}
+	for _, child := range exported.Children {
+		childNode, err := r.importNode(ctx, child, created.PublicId, userId)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, childNode)
+	}
+	return node, nil
+}
+
+// createImportedRole recreates a single exported role (with its grants and
+// principals) under scopeId, inside its own transaction. It mirrors the
+// role-creation half of writeScopeCreate -- including writing every
+// insert through the scope's oplog wrapper -- but against an
+// already-existing scope rather than one being created in the same
+// transaction.
+func (r *Repository) createImportedRole(ctx context.Context, scopeId string, exported exportedRole) (*Role, error) {
+	role, err := NewRole(scopeId)
+	if err != nil {
+		return nil, err
+	}
+	rolePublicId, err := newRoleId()
+	if err != nil {
+		return nil, err
+	}
+	role.PublicId = rolePublicId
+	role.Name = exported.Name
+	role.Description = exported.Description
+
+	// Resolve every principal reference to a public id on this cluster
+	// before opening the transaction, the same fail-fast-before-any-writes
+	// shape prepareScopeCreate uses for scope creation.
+	principalIds := make([]string, len(exported.Principals))
+	for i, p := range exported.Principals {
+		var id string
+		var err error
+		switch p.Type {
+		case "group":
+			id, err = r.lookupGroupByPrincipalRef(ctx, p.GroupName, p.GroupScopePath)
+		default:
+			id, err = r.lookupUserByPrincipalRef(ctx, p.AuthMethodName, p.LoginName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve principal for role %q: %w", exported.Name, err)
+		}
+		principalIds[i] = id
+	}
+
+	_, err = r.writer.DoTx(
+		ctx,
+		db.StdRetryCnt,
+		db.ExpBackoff{},
+		func(dbr db.Reader, w db.Writer) error {
+			kmsRepo, err := kms.NewRepository(dbr, w)
+			if err != nil {
+				return fmt.Errorf("error creating new kms repo: %w", err)
+			}
+			scopeOplogWrapper, err := r.kms.GetWrapper(ctx, scopeId, kms.KeyPurposeOplog, kms.WithRepository(kmsRepo))
+			if err != nil {
+				return fmt.Errorf("error fetching scope oplog wrapper: %w", err)
+			}
+
+			roleMetadata := oplog.Metadata{
+				"resource-public-id": []string{rolePublicId},
+				"scope-id":           []string{scopeId},
+				"resource-type":      []string{resource.Role.String()},
+				"op-type":            []string{oplog.OpType_OP_TYPE_CREATE.String()},
+			}
+			if err := w.Create(ctx, role, db.WithOplog(scopeOplogWrapper, roleMetadata)); err != nil {
+				return fmt.Errorf("error creating role: %w", err)
+			}
+
+			msgs := make([]*oplog.Message, 0, 1+len(exported.Grants)+len(exported.Principals))
+			roleTicket, err := w.GetTicket(role)
+			if err != nil {
+				return fmt.Errorf("unable to get ticket: %w", err)
+			}
+
+			// We need to update the role version as that's the aggregate
+			var roleOplogMsg oplog.Message
+			rowsUpdated, err := w.Update(ctx, role, []string{"Version"}, nil, db.NewOplogMsg(&roleOplogMsg), db.WithVersion(&role.Version))
+			if err != nil {
+				return fmt.Errorf("unable to update role version for adding grant: %w", err)
+			}
+			if rowsUpdated != 1 {
+				return fmt.Errorf("updated role but %d rows updated", rowsUpdated)
+			}
+			msgs = append(msgs, &roleOplogMsg)
+
+			if len(exported.Grants) > 0 {
+				grants := make([]interface{}, 0, len(exported.Grants))
+				for _, g := range exported.Grants {
+					roleGrant, err := NewRoleGrant(rolePublicId, g)
+					if err != nil {
+						return fmt.Errorf("unable to create in memory role grant: %w", err)
+					}
+					grants = append(grants, roleGrant)
+				}
+				roleGrantOplogMsgs := make([]*oplog.Message, 0, len(grants))
+				if err := w.CreateItems(ctx, grants, db.NewOplogMsgs(&roleGrantOplogMsgs)); err != nil {
+					return fmt.Errorf("unable to add grants: %w", err)
+				}
+				msgs = append(msgs, roleGrantOplogMsgs...)
+			}
+			if len(exported.Principals) > 0 {
+				principals := make([]interface{}, 0, len(exported.Principals))
+				for i, p := range exported.Principals {
+					var rolePrincipal interface{}
+					var err error
+					switch p.Type {
+					case "group":
+						rolePrincipal, err = NewGroupRole(rolePublicId, principalIds[i])
+					default:
+						rolePrincipal, err = NewUserRole(rolePublicId, principalIds[i])
+					}
+					if err != nil {
+						return fmt.Errorf("unable to create in memory role principal: %w", err)
+					}
+					principals = append(principals, rolePrincipal)
+				}
+				roleUserOplogMsgs := make([]*oplog.Message, 0, len(principals))
+				if err := w.CreateItems(ctx, principals, db.NewOplogMsgs(&roleUserOplogMsgs)); err != nil {
+					return fmt.Errorf("unable to add principals: %w", err)
+				}
+				msgs = append(msgs, roleUserOplogMsgs...)
+			}
+
+			return w.WriteOplogEntryWith(ctx, scopeOplogWrapper, roleTicket, roleMetadata, msgs)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
@@ -0,0 +1,156 @@
+package iam
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// TestRepository_ImportScopeTree_validation covers the validation and
+// document-parsing errors ImportScopeTree returns before ever creating a
+// scope, so it can run against a zero-value Repository.
+func TestRepository_ImportScopeTree_validation(t *testing.T) {
+	r := &Repository{}
+	validDoc, err := json.Marshal(ScopeTreeExport{
+		SchemaVersion: scopeTreeExportSchemaVersion,
+		Root:          &exportedScope{Name: "Acme Corp", Type: "org"},
+	})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	t.Run("missing new parent id", func(t *testing.T) {
+		if _, err := r.ImportScopeTree(context.Background(), validDoc, "", "u_1234567890"); err == nil {
+			t.Fatal("ImportScopeTree should error on a missing new parent id")
+		}
+	})
+
+	t.Run("missing user id", func(t *testing.T) {
+		if _, err := r.ImportScopeTree(context.Background(), validDoc, "o_1234567890", ""); err == nil {
+			t.Fatal("ImportScopeTree should error on a missing user id")
+		}
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		if _, err := r.ImportScopeTree(context.Background(), []byte("not json"), "o_1234567890", "u_1234567890"); err == nil {
+			t.Fatal("ImportScopeTree should error on malformed JSON")
+		}
+	})
+
+	t.Run("unsupported schema version", func(t *testing.T) {
+		data, err := json.Marshal(ScopeTreeExport{SchemaVersion: scopeTreeExportSchemaVersion + 1, Root: &exportedScope{Name: "Acme Corp", Type: "org"}})
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		if _, err := r.ImportScopeTree(context.Background(), data, "o_1234567890", "u_1234567890"); err == nil {
+			t.Fatal("ImportScopeTree should error on an unsupported schema version")
+		}
+	})
+
+	t.Run("missing root", func(t *testing.T) {
+		data, err := json.Marshal(ScopeTreeExport{SchemaVersion: scopeTreeExportSchemaVersion})
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		if _, err := r.ImportScopeTree(context.Background(), data, "o_1234567890", "u_1234567890"); err == nil {
+			t.Fatal("ImportScopeTree should error when the export has no root scope")
+		}
+	})
+}
+
+// TestExportedPrincipal_jsonShape verifies a user principal's JSON
+// serializes with only its (auth_method_name, login_name) fields, and a
+// group principal with only its (group_name, group_scope_path) fields --
+// the omitempty portable-reference shape ImportScopeTree depends on to
+// tell which of the two ways to resolve a principal back to a public id.
+func TestExportedPrincipal_jsonShape(t *testing.T) {
+	user := exportedPrincipal{Type: "user", AuthMethodName: "Primary Password", LoginName: "jdoe"}
+	data, err := json.Marshal(user)
+	if err != nil {
+		t.Fatalf("json.Marshal(user) error = %v", err)
+	}
+	var userFields map[string]interface{}
+	if err := json.Unmarshal(data, &userFields); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if _, ok := userFields["group_name"]; ok {
+		t.Error("a user principal should not serialize group_name")
+	}
+	if _, ok := userFields["group_scope_path"]; ok {
+		t.Error("a user principal should not serialize group_scope_path")
+	}
+	if userFields["login_name"] != "jdoe" {
+		t.Errorf("login_name = %v, want %q", userFields["login_name"], "jdoe")
+	}
+
+	group := exportedPrincipal{Type: "group", GroupName: "Payments Admins", GroupScopePath: []string{"Acme Corp", "payments"}}
+	data, err = json.Marshal(group)
+	if err != nil {
+		t.Fatalf("json.Marshal(group) error = %v", err)
+	}
+	var groupFields map[string]interface{}
+	if err := json.Unmarshal(data, &groupFields); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if _, ok := groupFields["auth_method_name"]; ok {
+		t.Error("a group principal should not serialize auth_method_name")
+	}
+	if _, ok := groupFields["login_name"]; ok {
+		t.Error("a group principal should not serialize login_name")
+	}
+
+	var roundTripped exportedPrincipal
+	if err := json.Unmarshal(data, &roundTripped); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if roundTripped.GroupName != group.GroupName || len(roundTripped.GroupScopePath) != len(group.GroupScopePath) {
+		t.Errorf("round-tripped group principal = %+v, want %+v", roundTripped, group)
+	}
+}
+
+// TestScopeTreeExport_jsonRoundTrip verifies a tree with nested children
+// and roles survives a marshal/unmarshal round trip, the same path
+// ExportScopeTree/ImportScopeTree communicate through.
+func TestScopeTreeExport_jsonRoundTrip(t *testing.T) {
+	doc := ScopeTreeExport{
+		SchemaVersion: scopeTreeExportSchemaVersion,
+		Root: &exportedScope{
+			Name: "Acme Corp",
+			Type: "org",
+			Roles: []exportedRole{
+				{
+					Name:   "Administration",
+					Grants: []string{"id=*;type=*;actions=*"},
+					Principals: []exportedPrincipal{
+						{Type: "user", AuthMethodName: "Primary Password", LoginName: "jdoe"},
+					},
+				},
+			},
+			Children: []*exportedScope{
+				{Name: "payments", Type: "project"},
+			},
+		},
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var got ScopeTreeExport
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if got.SchemaVersion != doc.SchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", got.SchemaVersion, doc.SchemaVersion)
+	}
+	if got.Root == nil || len(got.Root.Children) != 1 {
+		t.Fatalf("round-tripped root = %+v, want one child", got.Root)
+	}
+	if got.Root.Children[0].Name != "payments" {
+		t.Errorf("child name = %q, want %q", got.Root.Children[0].Name, "payments")
+	}
+	if len(got.Root.Roles) != 1 || len(got.Root.Roles[0].Principals) != 1 {
+		t.Fatalf("round-tripped roles = %+v, want one role with one principal", got.Root.Roles)
+	}
+}